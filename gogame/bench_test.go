@@ -0,0 +1,383 @@
+package gogame
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// Each speedup is measured against the code it replaced, kept below
+// Run with: go test -bench . -run ^$
+
+func BenchmarkPlayout(b *testing.B) {
+	for _, size := range []uint8{9, 19} {
+		b.Run(fmt.Sprintf("%dx%d/recursive", size, size), benchmarkPlayout(size, true))
+		b.Run(fmt.Sprintf("%dx%d/chainboard", size, size), benchmarkPlayout(size, false))
+	}
+}
+
+func BenchmarkLiberties(b *testing.B) {
+	b.Run("recursive", benchmarkBoards(19, func(board *Board, intn Intersection) {
+		if !board.isEmpty(intn) {
+			recursiveHasLiberty(board, intn)
+		}
+	}))
+	b.Run("bitboard", benchmarkBoards(19, func(board *Board, intn Intersection) {
+		if !board.isEmpty(intn) {
+			board.hasLiberty(intn)
+		}
+	}))
+}
+
+func BenchmarkRemoveChain(b *testing.B) {
+	b.Run("recursive", benchmarkBoards(19, func(board *Board, intn Intersection) {
+		tempBoard := *board
+		recursiveRemoveChain(&tempBoard, intn)
+	}))
+	b.Run("bitboard", benchmarkBoards(19, func(board *Board, intn Intersection) {
+		tempBoard := *board
+		tempBoard.removeChain(intn)
+	}))
+}
+
+func BenchmarkTerritory(b *testing.B) {
+	b.Run("recursive", benchmarkBoards(19, func(board *Board, intn Intersection) {
+		if board.isEmpty(intn) {
+			recursiveIsBlackTerritory(board, intn)
+		}
+	}))
+	b.Run("bitboard", benchmarkBoards(19, func(board *Board, intn Intersection) {
+		if board.isEmpty(intn) {
+			board.isBlackTerritory(intn)
+		}
+	}))
+}
+
+// The bitboard operations must agree with the recursive ones they are
+// measured against
+func TestBitboardMatchesRecursive(t *testing.T) {
+	for _, size := range []uint8{5, 9, 13} {
+		for k, board := range benchmarkGameBoards(size) {
+			pos := Position{board: board, blacksTurn: k%2 == 0}
+			for i := uint8(0); i < size; i++ {
+				for j := uint8(0); j < size; j++ {
+					intn := Intersection{i, j}
+					removed, recursiveRemoved := board, board
+					removed.removeChain(intn)
+					recursiveRemoveChain(&recursiveRemoved, intn)
+					if removed != recursiveRemoved {
+						t.Fatalf("%dx%d board %d: removing the chain on %s differs", size, size, k, intn)
+					}
+					if !board.isEmpty(intn) {
+						if board.hasLiberty(intn) != recursiveHasLiberty(&board, intn) {
+							t.Fatalf("%dx%d board %d: liberty of %s differs", size, size, k, intn)
+						}
+						continue
+					}
+					if board.isBlackTerritory(intn) != recursiveIsBlackTerritory(&board, intn) ||
+						board.isWhiteTerritory(intn) != recursiveIsWhiteTerritory(&board, intn) {
+						t.Fatalf("%dx%d board %d: territory of %s differs", size, size, k, intn)
+					}
+					black, recursiveBlack := board, board
+					black.fillSpaceBlack(intn)
+					recursiveFillSpaceBlack(&recursiveBlack, intn)
+					white, recursiveWhite := board, board
+					white.fillSpaceWhite(intn)
+					recursiveFillSpaceWhite(&recursiveWhite, intn)
+					if black != recursiveBlack || white != recursiveWhite {
+						t.Fatalf("%dx%d board %d: filling the space on %s differs", size, size, k, intn)
+					}
+					if pos.isEye(intn) != recursiveIsEye(&pos, intn) {
+						t.Fatalf("%dx%d board %d: eye on %s differs", size, size, k, intn)
+					}
+				}
+			}
+		}
+	}
+}
+
+// Returns a benchmark of an operation done at every intersection of some
+// boards from random games
+func benchmarkBoards(size uint8, operation func(board *Board, intn Intersection)) func(b *testing.B) {
+	return func(b *testing.B) {
+		boards := benchmarkGameBoards(size)
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			for k := range boards {
+				for i := uint8(0); i < size; i++ {
+					for j := uint8(0); j < size; j++ {
+						operation(&boards[k], Intersection{i, j})
+					}
+				}
+			}
+		}
+	}
+}
+
+// Returns every tenth board of a random game, which is the same every run
+func benchmarkGameBoards(size uint8) []Board {
+	random := rand.New(rand.NewSource(1))
+	player := FuncPlayer("random", RandomPlayer)
+	game := MakeGame(player, player, BoardSize(size))
+	boards := []Board{}
+	for k := 0; k < 3*int(size)*int(size) && !game.gameOver(); k++ {
+		pos := game.makeCurrentPosition()
+		moves := pos.LegalMoves()
+		move := PASS
+		if len(moves) > 0 && random.Intn(50) > 0 {
+			move = moves[random.Intn(len(moves))]
+		}
+		game.appendMove(move)
+		if k%10 == 0 {
+			boards = append(boards, game.BoardList[len(game.BoardList)-1])
+		}
+	}
+	return boards
+}
+
+// Returns a benchmark of playouts from the empty board, on plain boards
+// as before or on chain boards
+func benchmarkPlayout(size uint8, boards bool) func(b *testing.B) {
+	return func(b *testing.B) {
+		options := DefaultMCTSOptions()
+		var pos Position
+		pos.board = newBoard(size)
+		pos.blacksTurn = true
+		search := newMCTSSearch(options, rand.New(rand.NewSource(1)), pos)
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			var played [2]moveSet
+			if boards {
+				search.boardPlayout(search.root, &played)
+			} else {
+				search.playout(search.root, &played)
+			}
+		}
+	}
+}
+
+// The playout as it was before chain boards and bitboards, which copies
+// the board and searches for liberties recursively on every move, kept
+// to measure against
+func (search *mctsSearch) boardPlayout(node *mctsNode, played *[2]moveSet) float64 {
+	pos := Position{board: node.pos.board, blacksTurn: node.pos.blacksTurn}
+	var previous Board
+	if node.parent != nil {
+		previous = node.parent.pos.board
+	}
+	prisoners := node.prisoners
+	passes := node.passes
+	size := int(pos.board.size)
+	empty := make([]Intersection, 0, size*size)
+	for moves := 0; passes < 2 && moves < 3*size*size; moves++ {
+		// List the empty points, and try them in random order
+		empty = empty[:0]
+		for i := uint8(0); i < pos.board.size; i++ {
+			for j := uint8(0); j < pos.board.size; j++ {
+				if pos.board.isEmpty(Intersection{i, j}) {
+					empty = append(empty, Intersection{i, j})
+				}
+			}
+		}
+		moved := false
+		for len(empty) > 0 {
+			k := search.random.Intn(len(empty))
+			intn := empty[k]
+			empty[k] = empty[len(empty)-1]
+			empty = empty[:len(empty)-1]
+			if recursiveIsEye(&pos, intn) {
+				continue
+			}
+			tempBoard := pos.board
+			taken := recursivePlayCounting(&tempBoard, pos.blacksTurn, intn)
+			// Suicide and simple ko
+			if tempBoard.isEmpty(intn) && (!search.options.Rules.Suicide || tempBoard == pos.board) {
+				continue
+			}
+			if tempBoard == previous {
+				continue
+			}
+			previous = pos.board
+			pos.board = tempBoard
+			prisoners += taken
+			played[colorIndex(pos.blacksTurn)].add(intn)
+			moved = true
+			break
+		}
+		if moved {
+			passes = 0
+		} else {
+			previous = pos.board
+			passes++
+		}
+		pos.blacksTurn = !pos.blacksTurn
+	}
+	return boardScoreMargin(&pos.board, search.options.Rules, search.options.Komi, prisoners)
+}
+
+// Returns black's winning margin on a finished plain board, as
+// scoreMargin did before it took off dead stones
+func boardScoreMargin(board *Board, rules Ruleset, komi float64, prisoners int) float64 {
+	blackScore, whiteScore := recursiveScoring(board)
+	if rules.Scoring == TerritoryScoring {
+		blackStones, whiteStones := board.countStones()
+		blackScore += prisoners - blackStones
+		whiteScore -= whiteStones
+	}
+	return float64(blackScore-whiteScore) - komi
+}
+
+// The recursive board operations replaced by bitboards, kept to measure
+// against
+
+// Plays a stone as playCounting, with the recursive board operations
+func recursivePlayCounting(board *Board, black bool, intn Intersection) int {
+	blackBefore, whiteBefore := board.countStones()
+	if black {
+		board.placeBlackStone(intn)
+	} else {
+		board.placeWhiteStone(intn)
+	}
+	for _, adjIntn := range intn.adjacents(board.size) {
+		if !board.isEmpty(adjIntn) && !board.sameColor(intn, adjIntn) && !recursiveHasLiberty(board, adjIntn) {
+			recursiveRemoveChain(board, adjIntn)
+		}
+	}
+	if !recursiveHasLiberty(board, intn) {
+		recursiveRemoveChain(board, intn)
+	}
+	blackAfter, whiteAfter := board.countStones()
+	if black {
+		return (whiteBefore - whiteAfter) - (blackBefore + 1 - blackAfter)
+	}
+	return (whiteBefore + 1 - whiteAfter) - (blackBefore - blackAfter)
+}
+
+// Is this an eye for the person on move, as Position.isEye
+func recursiveIsEye(pos *Position, intn Intersection) bool {
+	for _, adjIntn := range intn.adjacents(pos.board.size) {
+		if pos.blacksTurn {
+			if !pos.board.isBlackStone(adjIntn) {
+				return false
+			}
+		} else {
+			if !pos.board.isWhiteStone(adjIntn) {
+				return false
+			}
+		}
+	}
+	tempBoard := pos.board
+	recursiveRemoveChain(&tempBoard, intn.adjacents(pos.board.size)[0])
+
+	for _, adjIntn := range intn.adjacents(pos.board.size) {
+		if !tempBoard.isEmpty(adjIntn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Removes all stones in a chain
+func recursiveRemoveChain(board *Board, intn Intersection) {
+	// If intersection is empty, we are done
+	if board.isEmpty(intn) {
+		return
+	}
+	// List of adjacent stones to remove
+	toRemove := make([]Intersection, 0, 4)
+	for _, adjIntn := range intn.adjacents(board.size) {
+		if board.sameColor(intn, adjIntn) {
+			toRemove = append(toRemove, adjIntn)
+		}
+	}
+	// Remove current stone
+	board.clearIntersection(intn)
+	// Loop through toRemove
+	for _, sameColorIntn := range toRemove {
+		recursiveRemoveChain(board, sameColorIntn)
+	}
+}
+
+// Fills empty space with black stones
+func recursiveFillSpaceBlack(board *Board, intn Intersection) {
+	// If intersection is not empty, we are done
+	if !board.isEmpty(intn) {
+		return
+	}
+	board.placeBlackStone(intn)
+	// Fill next to intersection
+	for _, adjIntn := range intn.adjacents(board.size) {
+		recursiveFillSpaceBlack(board, adjIntn)
+	}
+}
+
+// Fills empty space with White stones
+func recursiveFillSpaceWhite(board *Board, intn Intersection) {
+	// If intersection is not empty, we are done
+	if !board.isEmpty(intn) {
+		return
+	}
+	board.placeWhiteStone(intn)
+	// Fill next to intersection
+	for _, adjIntn := range intn.adjacents(board.size) {
+		recursiveFillSpaceWhite(board, adjIntn)
+	}
+}
+
+// Asks if the stone at the given intersection has a liberty.
+func recursiveHasLiberty(board *Board, intn Intersection) bool {
+	// Is stone black?
+	blackStone := board.isBlackStone(intn)
+	// Create tempBoard
+	var tempBoard Board = *board
+	// Remove the chain
+	recursiveRemoveChain(&tempBoard, intn)
+	// Fill the space, and compare with original board
+	if blackStone {
+		recursiveFillSpaceBlack(&tempBoard, intn)
+		return tempBoard.black != board.black
+	} else {
+		recursiveFillSpaceWhite(&tempBoard, intn)
+		return tempBoard.white != board.white
+	}
+}
+
+// Asks if the empty space at the given intersection is black territory
+func recursiveIsBlackTerritory(board *Board, intn Intersection) bool {
+	// Create tempBoard
+	var tempBoard Board = *board
+	// Fill the space with white, remove the white chain, and compare
+	recursiveFillSpaceWhite(&tempBoard, intn)
+	recursiveRemoveChain(&tempBoard, intn)
+	return tempBoard.white == board.white
+}
+
+// Asks if the empty space at the given intersection is white territory
+func recursiveIsWhiteTerritory(board *Board, intn Intersection) bool {
+	// Create tempBoard
+	var tempBoard Board = *board
+	// Fill the space with black, remove the white chain, and compare
+	recursiveFillSpaceBlack(&tempBoard, intn)
+	recursiveRemoveChain(&tempBoard, intn)
+	return tempBoard.black == board.black
+}
+
+// Scores the board by area, as chineseScoring did before it took off
+// dead stones
+func recursiveScoring(board *Board) (int, int) {
+	var scoreBoard Board = *board
+	for i := uint8(0); i < board.size; i++ {
+		for j := uint8(0); j < board.size; j++ {
+			intn := Intersection{i, j}
+			// If empty, see if it can be filled
+			if scoreBoard.isEmpty(intn) {
+				if recursiveIsBlackTerritory(&scoreBoard, intn) {
+					recursiveFillSpaceBlack(&scoreBoard, intn)
+				} else if recursiveIsWhiteTerritory(&scoreBoard, intn) {
+					recursiveFillSpaceWhite(&scoreBoard, intn)
+				}
+			}
+		}
+	}
+	return scoreBoard.countStones()
+}
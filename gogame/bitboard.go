@@ -0,0 +1,175 @@
+package gogame
+
+import (
+	"math/bits"
+)
+
+// A set of intersections as row bitmaps, like the stones of a Board
+// Chains, liberties and regions are found by dilating a set one step in
+// every direction and masking it, until it stops growing
+type bitboard [MAX_SIZE]uint32
+
+// Returns the set holding only the intersection
+func pointMask(intn Intersection) bitboard {
+	var bb bitboard
+	bb[intn.x] = 1 << intn.y
+	return bb
+}
+
+// Asks if the set holds the intersection
+func (bb *bitboard) has(intn Intersection) bool {
+	return bb[intn.x]&(1<<intn.y) != 0
+}
+
+// Asks if the set is empty
+func (bb *bitboard) isZero() bool {
+	return *bb == bitboard{}
+}
+
+// Returns the points in both sets
+func (bb bitboard) and(other bitboard) bitboard {
+	for i := range bb {
+		bb[i] &= other[i]
+	}
+	return bb
+}
+
+// Counts the points in the set
+func (bb *bitboard) count() int {
+	total := 0
+	for _, row := range bb {
+		total += bits.OnesCount32(row)
+	}
+	return total
+}
+
+// Returns the first point of a set that is not empty, row by row
+func (bb *bitboard) first() Intersection {
+	for i, row := range bb {
+		if row != 0 {
+			return Intersection{uint8(i), uint8(bits.TrailingZeros32(row))}
+		}
+	}
+	return PASS
+}
+
+// Returns the intersections of the set, row by row
+func (bb *bitboard) intersections() []Intersection {
+	intns := make([]Intersection, 0, bb.count())
+	for i, row := range bb {
+		for row != 0 {
+			j := bits.TrailingZeros32(row)
+			intns = append(intns, Intersection{uint8(i), uint8(j)})
+			row &= row - 1
+		}
+	}
+	return intns
+}
+
+// Returns the set with every point next to it added, on a board of the
+// given size
+func (bb *bitboard) dilate(size uint8) bitboard {
+	var grown bitboard
+	rowMask := uint32(1)<<size - 1
+	for i := uint8(0); i < size; i++ {
+		row := bb[i] | bb[i]<<1 | bb[i]>>1
+		if i > 0 {
+			row |= bb[i-1]
+		}
+		if i+1 < size {
+			row |= bb[i+1]
+		}
+		grown[i] = row & rowMask
+	}
+	return grown
+}
+
+// Grows the seed within the mask until it stops growing, giving the
+// connected part of the mask that holds the seed
+func flood(seed, within bitboard, size uint8) bitboard {
+	for {
+		grown := seed.dilate(size)
+		grown = grown.and(within)
+		if grown == seed {
+			return seed
+		}
+		seed = grown
+	}
+}
+
+// Splits the set into its connected parts, row by row of their first
+// points
+func (bb *bitboard) components(size uint8) []bitboard {
+	parts := []bitboard{}
+	left := *bb
+	for !left.isZero() {
+		part := flood(pointMask(left.first()), left, size)
+		parts = append(parts, part)
+		for i := range left {
+			left[i] &^= part[i]
+		}
+	}
+	return parts
+}
+
+// Returns the empty points of the board
+func (board *Board) emptyMask() bitboard {
+	var empty bitboard
+	rowMask := uint32(1)<<board.size - 1
+	for i := uint8(0); i < board.size; i++ {
+		empty[i] = ^(board.black[i] | board.white[i]) & rowMask
+	}
+	return empty
+}
+
+// Returns the stones of the chain on an intersection, or the empty
+// region holding it
+func (board *Board) chainMask(intn Intersection) bitboard {
+	var within bitboard
+	if board.isBlackStone(intn) {
+		within = board.black
+	} else if board.isWhiteStone(intn) {
+		within = board.white
+	} else {
+		within = board.emptyMask()
+	}
+	return flood(pointMask(intn), within, board.size)
+}
+
+// Returns the liberties of a set of stones
+func (board *Board) libertiesMask(chain *bitboard) bitboard {
+	grown := chain.dilate(board.size)
+	return grown.and(board.emptyMask())
+}
+
+// Asks if the points next to a set include any stones of a color
+func (board *Board) touches(region *bitboard, black bool) bool {
+	stones := bitboard(board.white)
+	if black {
+		stones = board.black
+	}
+	grown := region.dilate(board.size)
+	grown = grown.and(stones)
+	return !grown.isZero()
+}
+
+// Clears every point of a set
+func (board *Board) clearMask(mask *bitboard) {
+	for _, intn := range mask.intersections() {
+		board.clearIntersection(intn)
+	}
+}
+
+// Puts a black stone on every point of a set
+func (board *Board) fillMaskBlack(mask *bitboard) {
+	for _, intn := range mask.intersections() {
+		board.placeBlackStone(intn)
+	}
+}
+
+// Puts a white stone on every point of a set
+func (board *Board) fillMaskWhite(mask *bitboard) {
+	for _, intn := range mask.intersections() {
+		board.placeWhiteStone(intn)
+	}
+}
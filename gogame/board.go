@@ -5,7 +5,13 @@ import (
 	"strconv"
 )
 
-const SIZE uint8 = 9
+// Bounds on the board size, which is chosen when a game is made.
+// Each row is stored as a uint32 bitmap, so sizes must stay under 32.
+const MIN_SIZE uint8 = 2
+const MAX_SIZE uint8 = 25
+
+// The board size used when none is given
+const DEFAULT_SIZE uint8 = 9
 
 // Struct to hold the coordinates of an intersection on the board
 type Intersection struct {
@@ -13,22 +19,37 @@ type Intersection struct {
 	y uint8
 }
 
+// Returns the coordinates of the intersection, as entered by HumanPlayer
+func (intn Intersection) String() string {
+	if intn == PASS {
+		return "pass"
+	}
+	if intn == RESIGN {
+		return "resign"
+	}
+	if intn == UNDO {
+		return "undo"
+	}
+	return fmt.Sprintf("%d %d", intn.x, intn.y)
+}
+
 // Returns a slice of Intersections (cap 4)
-// This contains every adjacent intersection to the given intersection.
-func (intn *Intersection) adjacents() []Intersection {
+// This contains every adjacent intersection to the given intersection
+// on a board of the given size.
+func (intn *Intersection) adjacents(size uint8) []Intersection {
 	// intn x and y are uints, so thet are always positive
-	// We must only check that they are under the SIZE value
+	// We must only check that they are under the size value
 	adj := make([]Intersection, 0, 4)
-	if intn.x+1 < SIZE {
+	if intn.x+1 < size {
 		adj = append(adj, Intersection{intn.x + 1, intn.y})
 	}
-	if intn.y+1 < SIZE {
+	if intn.y+1 < size {
 		adj = append(adj, Intersection{intn.x, intn.y + 1})
 	}
-	if intn.x-1 < SIZE {
+	if intn.x-1 < size {
 		adj = append(adj, Intersection{intn.x - 1, intn.y})
 	}
-	if intn.y-1 < SIZE {
+	if intn.y-1 < size {
 		adj = append(adj, Intersection{intn.x, intn.y - 1})
 	}
 	return adj
@@ -36,9 +57,32 @@ func (intn *Intersection) adjacents() []Intersection {
 
 // Struct to hold the current position on the board
 // Bitmaps hold locations of stones of a given color
+// Only the first size rows and columns are used
+// The Zobrist hash of the stones is kept up to date as they change
 type Board struct {
-	black [SIZE]uint32
-	white [SIZE]uint32
+	size  uint8
+	black [MAX_SIZE]uint32
+	white [MAX_SIZE]uint32
+	hash  uint64
+}
+
+// Makes an empty board of the given size
+// Panics if the size is out of the supported range
+func newBoard(size uint8) Board {
+	if size < MIN_SIZE || size > MAX_SIZE {
+		panic("Board size out of range")
+	}
+	return Board{size: size}
+}
+
+// Returns the number of rows (and columns) of the board
+func (board *Board) Size() uint8 {
+	return board.size
+}
+
+// Returns whether the intersection lies on the board
+func (board *Board) onBoard(i Intersection) bool {
+	return i.x < board.size && i.y < board.size
 }
 
 // Returns whether a given intersection (param i) holds a black stone
@@ -75,14 +119,19 @@ func (board *Board) sameColor(i1, i2 Intersection) bool {
  * uses b to represent black stones
  */
 func (board *Board) PrintOut() {
+	fmt.Print(board.String())
+}
+
+// Returns the textual display of the board printed by PrintOut
+func (board *Board) String() string {
 	var rowString string = "  "
-	for i := uint8(0); i < SIZE; i++ {
+	for i := uint8(0); i < board.size; i++ {
 		rowString += strconv.Itoa(int(i)%10) + " "
 	}
-	fmt.Println(rowString)
-	for i := uint8(0); i < SIZE; i++ {
+	display := rowString + "\n"
+	for i := uint8(0); i < board.size; i++ {
 		var rowString string = strconv.Itoa(int(i)%10) + " "
-		for j := uint8(0); j < SIZE; j++ {
+		for j := uint8(0); j < board.size; j++ {
 			if board.isBlackStone(Intersection{i, j}) {
 				rowString += "b "
 			} else if board.isWhiteStone(Intersection{i, j}) {
@@ -91,9 +140,9 @@ func (board *Board) PrintOut() {
 				rowString += "+ "
 			}
 		}
-		fmt.Println(rowString)
+		display += rowString + "\n"
 	}
-
+	return display
 }
 
 /**
@@ -101,6 +150,12 @@ func (board *Board) PrintOut() {
  * Does not check if space is occupied or for captures.
  */
 func (board *Board) placeBlackStone(i Intersection) {
+	if board.isWhiteStone(i) {
+		board.hash ^= zobristWhite[i.x][i.y]
+	}
+	if !board.isBlackStone(i) {
+		board.hash ^= zobristBlack[i.x][i.y]
+	}
 	board.white[i.x] &^= 1 << i.y
 	board.black[i.x] |= 1 << i.y
 }
@@ -110,6 +165,12 @@ func (board *Board) placeBlackStone(i Intersection) {
  * Does not check if space is occupied or for captures.
  */
 func (board *Board) placeWhiteStone(i Intersection) {
+	if board.isBlackStone(i) {
+		board.hash ^= zobristBlack[i.x][i.y]
+	}
+	if !board.isWhiteStone(i) {
+		board.hash ^= zobristWhite[i.x][i.y]
+	}
 	board.white[i.x] |= 1 << i.y
 	board.black[i.x] &^= 1 << i.y
 }
@@ -131,29 +192,24 @@ func (board *Board) switchStoneColor(i Intersection) {
  * Does not check if space is occupied
  */
 func (board *Board) clearIntersection(i Intersection) {
+	if board.isBlackStone(i) {
+		board.hash ^= zobristBlack[i.x][i.y]
+	}
+	if board.isWhiteStone(i) {
+		board.hash ^= zobristWhite[i.x][i.y]
+	}
 	board.white[i.x] &^= 1 << i.y
 	board.black[i.x] &^= 1 << i.y
 }
 
-// Removes all stones in a chain, and returns the number of stones removed
+// Removes all stones in the chain at the intersection, if there is one
 func (board *Board) removeChain(intn Intersection) {
 	// If intersection is empty, we are done
 	if board.isEmpty(intn) {
 		return
 	}
-	// List of adjacent stones to remove
-	toRemove := make([]Intersection, 0, 4)
-	for _, adjIntn := range intn.adjacents() {
-		if board.sameColor(intn, adjIntn) {
-			toRemove = append(toRemove, adjIntn)
-		}
-	}
-	// Remove current stone
-	board.clearIntersection(intn)
-	// Loop through toRemove
-	for _, sameColorIntn := range toRemove {
-		board.removeChain(sameColorIntn)
-	}
+	chain := board.chainMask(intn)
+	board.clearMask(&chain)
 }
 
 // Fills empty space with black stones
@@ -162,11 +218,8 @@ func (board *Board) fillSpaceBlack(intn Intersection) {
 	if !board.isEmpty(intn) {
 		return
 	}
-	board.placeBlackStone(intn)
-	// Fill next to intersection
-	for _, adjIntn := range intn.adjacents() {
-		board.fillSpaceBlack(adjIntn)
-	}
+	region := board.chainMask(intn)
+	board.fillMaskBlack(&region)
 }
 
 // Fills empty space with White stones
@@ -175,11 +228,8 @@ func (board *Board) fillSpaceWhite(intn Intersection) {
 	if !board.isEmpty(intn) {
 		return
 	}
-	board.placeWhiteStone(intn)
-	// Fill next to intersection
-	for _, adjIntn := range intn.adjacents() {
-		board.fillSpaceWhite(adjIntn)
-	}
+	region := board.chainMask(intn)
+	board.fillMaskWhite(&region)
 }
 
 // Enacts the play of a black stone at the given empty intersection
@@ -188,7 +238,7 @@ func (board *Board) playBlackStone(intn Intersection) {
 		panic("Tried to play black stone in nonempty intersection")
 	}
 	board.placeBlackStone(intn)
-	for _, adjIntn := range intn.adjacents() {
+	for _, adjIntn := range intn.adjacents(board.size) {
 		if board.isWhiteStone(adjIntn) && !board.hasLiberty(adjIntn) {
 			board.removeChain(adjIntn)
 		}
@@ -204,7 +254,7 @@ func (board *Board) playWhiteStone(intn Intersection) {
 		panic("Tried to play black stone in nonempty intersection")
 	}
 	board.placeWhiteStone(intn)
-	for _, adjIntn := range intn.adjacents() {
+	for _, adjIntn := range intn.adjacents(board.size) {
 		if board.isBlackStone(adjIntn) && !board.hasLiberty(adjIntn) {
 			board.removeChain(adjIntn)
 		}
@@ -222,62 +272,58 @@ func (board *Board) hasLiberty(intn Intersection) bool {
 	if board.isEmpty(intn) {
 		panic("Tried to find liberties for empty intersection")
 	}
-	// Is stone black?
-	blackStone := board.isBlackStone(intn)
-	// Create tempBoard
-	var tempBoard Board = *board
-	// Remove the chain
-	tempBoard.removeChain(intn)
-	// Fill the space, and compare with original board
-	if blackStone {
-		tempBoard.fillSpaceBlack(intn)
-		return tempBoard.black != board.black
-	} else {
-		tempBoard.fillSpaceWhite(intn)
-		return tempBoard.white != board.white
-	}
+	chain := board.chainMask(intn)
+	liberties := board.libertiesMask(&chain)
+	return !liberties.isZero()
 }
 
-// Asks if the empty space at the given intersection is black territory
+// Asks if the empty space at the given intersection is black territory,
+// which is when it touches no white stone
 // Requires that the Intersection intn be empty
 func (board *Board) isBlackTerritory(intn Intersection) bool {
 	// We first ensure that our intersection is empty
 	if !board.isEmpty(intn) {
 		panic("Tried to test black territory on nonempty intersection")
 	}
-	// Create tempBoard
-	var tempBoard Board = *board
-	// Fill the space with white, remove the white chain, and compare
-	tempBoard.fillSpaceWhite(intn)
-	tempBoard.removeChain(intn)
-	return tempBoard.white == board.white
+	region := board.chainMask(intn)
+	return !board.touches(&region, false)
 }
 
-// Asks if the empty space at the given intersection is white territory
+// Asks if the empty space at the given intersection is white territory,
+// which is when it touches no black stone
 // Requires that the Intersection intn be empty
 func (board *Board) isWhiteTerritory(intn Intersection) bool {
 	// We first ensure that our intersection is empty
 	if !board.isEmpty(intn) {
 		panic("Tried to test white territory on nonempty intersection")
 	}
-	// Create tempBoard
-	var tempBoard Board = *board
-	// Fill the space with black, remove the white chain, and compare
-	tempBoard.fillSpaceBlack(intn)
-	tempBoard.removeChain(intn)
-	return tempBoard.black == board.black
+	region := board.chainMask(intn)
+	return !board.touches(&region, true)
 }
 
 type Position struct {
 	board      Board
 	blacksTurn bool
-	illegal    [SIZE]uint32
+	illegal    [MAX_SIZE]uint32
+	// The rules moves from the position are played under
+	rules Ruleset
+	// The board before the last move, for simple ko
+	previous Board
+	// Ko keys of every position of the game so far, this one included
+	history []uint64
+	// Prisoners taken by black and by white
+	captures [2]int
 }
 
 func (pos *Position) setIllegal(i Intersection) {
 	pos.illegal[i.x] |= 1 << i.y
 }
 
+// Returns whether a move is legal, so on the board and not marked illegal
+// Passing and resigning are always legal
 func (pos *Position) isLegal(i Intersection) bool {
-	return i == PASS || pos.illegal[i.x]&(1<<i.y) == 0
+	if i == PASS || i == RESIGN {
+		return true
+	}
+	return pos.board.onBoard(i) && pos.illegal[i.x]&(1<<i.y) == 0
 }
@@ -0,0 +1,268 @@
+package gogame
+
+// Cells of the largest chain board: the points, a border row above and
+// below, and a border column shared by the ends of neighbouring rows
+const MAX_CELLS int = (int(MAX_SIZE)+2)*(int(MAX_SIZE)+1) + 1
+
+// Marks the border cells of a chain board
+const border Color = 3
+
+// A board that keeps track of its chains as stones are played, for fast
+// playouts
+// The stones of a chain form a circular list, and the chain counts its
+// pseudo-liberties: each empty point next to one of its stones, once for
+// every stone it touches. The sum and sum of squares of their cells show
+// when they are all the same point, which is when the chain is in atari
+type chainBoard struct {
+	size uint8
+	// Cells per row, including the border column
+	width int
+	color [MAX_CELLS]Color
+	// The head of each stone's chain, and the next stone in the chain
+	head [MAX_CELLS]int16
+	next [MAX_CELLS]int16
+	// Stones and pseudo-liberties of each chain, kept at its head
+	stones   [MAX_CELLS]int16
+	libs     [MAX_CELLS]int16
+	libSum   [MAX_CELLS]int32
+	libSumSq [MAX_CELLS]int64
+	// The point the player to move may not take back a ko on, or -1
+	ko int
+}
+
+// Makes a chain board with the stones of the board
+// The board must have no chains without liberties
+func makeChainBoard(board *Board) chainBoard {
+	cb := chainBoard{size: board.size, width: int(board.size) + 1, ko: -1}
+	for cell := range cb.color {
+		cb.color[cell] = border
+	}
+	for i := uint8(0); i < board.size; i++ {
+		for j := uint8(0); j < board.size; j++ {
+			cb.color[cb.cell(Intersection{i, j})] = Empty
+		}
+	}
+	for i := uint8(0); i < board.size; i++ {
+		for j := uint8(0); j < board.size; j++ {
+			intn := Intersection{i, j}
+			if board.isBlackStone(intn) {
+				cb.addStone(cb.cell(intn), Black)
+			} else if board.isWhiteStone(intn) {
+				cb.addStone(cb.cell(intn), White)
+			}
+		}
+	}
+	return cb
+}
+
+// Returns a plain board with the stones of the chain board
+func (cb *chainBoard) board() Board {
+	board := newBoard(cb.size)
+	for i := uint8(0); i < cb.size; i++ {
+		for j := uint8(0); j < cb.size; j++ {
+			intn := Intersection{i, j}
+			switch cb.color[cb.cell(intn)] {
+			case Black:
+				board.placeBlackStone(intn)
+			case White:
+				board.placeWhiteStone(intn)
+			}
+		}
+	}
+	return board
+}
+
+// Returns the cell of an intersection
+func (cb *chainBoard) cell(intn Intersection) int {
+	return (int(intn.x)+1)*cb.width + int(intn.y)
+}
+
+// Returns the intersection of a cell on the board
+func (cb *chainBoard) intersection(cell int) Intersection {
+	return Intersection{uint8(cell/cb.width - 1), uint8(cell % cb.width)}
+}
+
+// Returns the four cells next to a cell, some of which may be border
+func (cb *chainBoard) neighbours(cell int) [4]int {
+	return [4]int{cell - cb.width, cell - 1, cell + 1, cell + cb.width}
+}
+
+// Counts an empty cell as a pseudo-liberty of a chain
+func (cb *chainBoard) addLiberty(head int, cell int) {
+	cb.libs[head]++
+	cb.libSum[head] += int32(cell)
+	cb.libSumSq[head] += int64(cell) * int64(cell)
+}
+
+// Stops counting a cell, now filled, as a pseudo-liberty of a chain
+func (cb *chainBoard) removeLiberty(head int, cell int) {
+	cb.libs[head]--
+	cb.libSum[head] -= int32(cell)
+	cb.libSumSq[head] -= int64(cell) * int64(cell)
+}
+
+// Asks if a chain has only one liberty
+func (cb *chainBoard) inAtari(head int) bool {
+	libs := int64(cb.libs[head])
+	sum := int64(cb.libSum[head])
+	return libs > 0 && libs*cb.libSumSq[head] == sum*sum
+}
+
+// Returns the only liberty of a chain in atari
+func (cb *chainBoard) atariLiberty(head int) int {
+	return int(cb.libSum[head]) / int(cb.libs[head])
+}
+
+// Puts a stone on an empty cell, joining it to the chains next to it and
+// taking the cell from their liberties
+// Nothing is captured
+func (cb *chainBoard) addStone(cell int, color Color) {
+	cb.color[cell] = color
+	cb.head[cell] = int16(cell)
+	cb.next[cell] = int16(cell)
+	cb.stones[cell] = 1
+	cb.libs[cell] = 0
+	cb.libSum[cell] = 0
+	cb.libSumSq[cell] = 0
+	for _, adj := range cb.neighbours(cell) {
+		switch cb.color[adj] {
+		case Empty:
+			cb.addLiberty(cell, adj)
+		case Black, White:
+			cb.removeLiberty(int(cb.head[adj]), cell)
+		}
+	}
+	for _, adj := range cb.neighbours(cell) {
+		if cb.color[adj] == color && cb.head[adj] != cb.head[cell] {
+			cb.mergeChains(int(cb.head[cell]), int(cb.head[adj]))
+		}
+	}
+}
+
+// Joins two chains, the smaller taking the head of the larger
+func (cb *chainBoard) mergeChains(a, b int) {
+	if cb.stones[a] < cb.stones[b] {
+		a, b = b, a
+	}
+	stone := b
+	for {
+		cb.head[stone] = int16(a)
+		stone = int(cb.next[stone])
+		if stone == b {
+			break
+		}
+	}
+	cb.next[a], cb.next[b] = cb.next[b], cb.next[a]
+	cb.stones[a] += cb.stones[b]
+	cb.libs[a] += cb.libs[b]
+	cb.libSum[a] += cb.libSum[b]
+	cb.libSumSq[a] += cb.libSumSq[b]
+}
+
+// Takes a chain off the board, giving its cells to the chains next to
+// them as liberties
+// Returns the number of stones removed
+func (cb *chainBoard) removeChain(head int) int {
+	stone := head
+	for {
+		cb.color[stone] = Empty
+		stone = int(cb.next[stone])
+		if stone == head {
+			break
+		}
+	}
+	for {
+		for _, adj := range cb.neighbours(stone) {
+			if cb.color[adj] == Black || cb.color[adj] == White {
+				cb.addLiberty(int(cb.head[adj]), stone)
+			}
+		}
+		stone = int(cb.next[stone])
+		if stone == head {
+			break
+		}
+	}
+	return int(cb.stones[head])
+}
+
+// Asks if a color may play on a cell: it must be empty, not retake a ko,
+// and not be suicide, unless suicide is allowed and more than one stone
+// is lost
+func (cb *chainBoard) isLegal(cell int, color Color, suicide bool) bool {
+	if cb.color[cell] != Empty || cell == cb.ko {
+		return false
+	}
+	friends := false
+	for _, adj := range cb.neighbours(cell) {
+		switch cb.color[adj] {
+		case Empty:
+			return true
+		case color:
+			friends = true
+			head := int(cb.head[adj])
+			if !cb.inAtari(head) || cb.atariLiberty(head) != cell {
+				return true
+			}
+		case border:
+		default:
+			// Capturing an opponent chain frees the cell
+			if cb.inAtari(int(cb.head[adj])) {
+				return true
+			}
+		}
+	}
+	return suicide && friends
+}
+
+// Asks if a cell is an eye of the color: every neighbour is a stone of a
+// single chain of that color
+func (cb *chainBoard) isEye(cell int, color Color) bool {
+	head := int16(-1)
+	for _, adj := range cb.neighbours(cell) {
+		switch cb.color[adj] {
+		case border:
+		case color:
+			if head >= 0 && cb.head[adj] != head {
+				return false
+			}
+			head = cb.head[adj]
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Plays a stone of the color on a legal cell, capturing as needed
+// Returns the number of opponent stones captured, and the number of the
+// player's own stones lost to suicide
+func (cb *chainBoard) play(cell int, color Color) (int, int) {
+	cb.addStone(cell, color)
+	captured := 0
+	koCell := -1
+	for _, adj := range cb.neighbours(cell) {
+		if cb.color[adj] == color.Opponent() && cb.libs[cb.head[adj]] == 0 {
+			if cb.stones[cb.head[adj]] == 1 {
+				koCell = adj
+			}
+			captured += cb.removeChain(int(cb.head[adj]))
+		}
+	}
+	lost := 0
+	head := int(cb.head[cell])
+	if cb.libs[head] == 0 {
+		lost = cb.removeChain(head)
+	}
+	// A single stone taking a single stone, and left with only that point
+	// as liberty, may not be taken back at once
+	cb.ko = -1
+	if captured == 1 && lost == 0 && cb.stones[head] == 1 && cb.libs[head] == 1 {
+		cb.ko = koCell
+	}
+	return captured, lost
+}
+
+// Passes, which ends any ko
+func (cb *chainBoard) pass() {
+	cb.ko = -1
+}
@@ -0,0 +1,113 @@
+package gogame
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Plays the same random games on a chain board and a plain board, and
+// checks that they agree on every legal move, capture and ko
+func TestChainBoardMatchesBoard(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for game := 0; game < 100; game++ {
+		size := uint8(5 + random.Intn(5))
+		suicide := game%2 == 1
+		board := newBoard(size)
+		cb := makeChainBoard(&board)
+		ko := PASS
+		color := Black
+		for move := 0; move < 3*int(size)*int(size); move++ {
+			var legal []Intersection
+			for i := uint8(0); i < size; i++ {
+				for j := uint8(0); j < size; j++ {
+					intn := Intersection{i, j}
+					want := referenceLegal(&board, intn, color, ko, suicide)
+					if got := cb.isLegal(cb.cell(intn), color, suicide); got != want {
+						t.Fatalf("game %d move %d: %s on %s legal %v on the chain board, want %v", game, move, color, intn, got, want)
+					}
+					if want {
+						legal = append(legal, intn)
+					}
+				}
+			}
+			if len(legal) == 0 || random.Intn(20) == 0 {
+				cb.pass()
+				ko = PASS
+				color = color.Opponent()
+				continue
+			}
+			intn := legal[random.Intn(len(legal))]
+			next, nextKo, _ := board.readMove(intn, color == Black, PASS)
+			if next.isEmpty(intn) {
+				// Suicide, which readMove does not play
+				next = board
+				if color == Black {
+					next.playBlackStone(intn)
+				} else {
+					next.playWhiteStone(intn)
+				}
+			}
+			blackBefore, whiteBefore := board.countStones()
+			blackAfter, whiteAfter := next.countStones()
+			// The player's own stones lost include the one played
+			wantCaptured, wantLost := whiteBefore-whiteAfter, blackBefore+1-blackAfter
+			if color == White {
+				wantCaptured, wantLost = blackBefore-blackAfter, whiteBefore+1-whiteAfter
+			}
+			captured, lost := cb.play(cb.cell(intn), color)
+			if captured != wantCaptured || lost != wantLost {
+				t.Fatalf("game %d move %d: %s on %s captured %d and lost %d, want %d and %d", game, move, color, intn, captured, lost, wantCaptured, wantLost)
+			}
+			board, ko = next, nextKo
+			if cb.board() != board {
+				t.Fatalf("game %d move %d: chain board differs after %s on %s", game, move, color, intn)
+			}
+			// The pseudo-liberties show atari exactly
+			for i := uint8(0); i < size; i++ {
+				for j := uint8(0); j < size; j++ {
+					stone := Intersection{i, j}
+					if board.isEmpty(stone) {
+						continue
+					}
+					chain := board.chainMask(stone)
+					liberties := board.libertiesMask(&chain)
+					if atari := cb.inAtari(int(cb.head[cb.cell(stone)])); atari != (liberties.count() == 1) {
+						t.Fatalf("game %d move %d: chain on %s in atari %v with %d liberties", game, move, stone, atari, liberties.count())
+					}
+				}
+			}
+			wantKo := -1
+			if ko != PASS {
+				wantKo = cb.cell(ko)
+			}
+			if cb.ko != wantKo {
+				t.Fatalf("game %d move %d: ko on cell %d, want %d", game, move, cb.ko, wantKo)
+			}
+			color = color.Opponent()
+		}
+	}
+}
+
+// Asks if a color may play on a point under simple ko, with suicide of
+// more than one stone allowed if suicide is
+func referenceLegal(board *Board, intn Intersection, color Color, ko Intersection, suicide bool) bool {
+	if _, _, ok := board.readMove(intn, color == Black, ko); ok {
+		return true
+	}
+	if !suicide || intn == ko || !board.isEmpty(intn) {
+		return false
+	}
+	next := *board
+	if color == Black {
+		next.playBlackStone(intn)
+	} else {
+		next.playWhiteStone(intn)
+	}
+	black, white := board.countStones()
+	blackAfter, whiteAfter := next.countStones()
+	if color == White {
+		black, blackAfter = white, whiteAfter
+	}
+	// The stone played and at least one more are lost
+	return black-blackAfter >= 1
+}
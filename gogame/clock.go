@@ -0,0 +1,331 @@
+package gogame
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The kinds of game clock
+type TimeSystem uint8
+
+const (
+	// No clock, players may take as long as they like
+	NoClock TimeSystem = iota
+	// Main time only
+	AbsoluteTime
+	// Main time, with an increment added after each move
+	FischerTime
+	// Main time, then periods of overtime, one of which is lost each
+	// time a move takes longer than a period
+	ByoYomiTime
+	// Main time, then periods in which a number of stones must be played
+	CanadianTime
+)
+
+// How long the players have to play
+type TimeControl struct {
+	System TimeSystem
+	// Time for the whole game, before any overtime
+	MainTime time.Duration
+	// Added to the main time after each move, for Fischer clocks
+	Increment time.Duration
+	// Length of each overtime period, for byo-yomi and Canadian clocks
+	Period time.Duration
+	// Number of byo-yomi periods
+	Periods int
+	// Stones to play in each Canadian period
+	Stones int
+	// Longest any single move may take, on top of the clock
+	// Zero for no limit
+	MoveLimit time.Duration
+}
+
+// Option to give each player main time only
+func AbsoluteClock(mainTime time.Duration) GameOption {
+	return func(config *GameConfig) {
+		config.Time = TimeControl{System: AbsoluteTime, MainTime: mainTime, MoveLimit: config.Time.MoveLimit}
+	}
+}
+
+// Option to give each player main time, with an increment after each move
+func FischerClock(mainTime, increment time.Duration) GameOption {
+	return func(config *GameConfig) {
+		config.Time = TimeControl{System: FischerTime, MainTime: mainTime, Increment: increment,
+			MoveLimit: config.Time.MoveLimit}
+	}
+}
+
+// Option to give each player main time, then the given number of
+// byo-yomi periods
+func ByoYomiClock(mainTime, period time.Duration, periods int) GameOption {
+	return func(config *GameConfig) {
+		config.Time = TimeControl{System: ByoYomiTime, MainTime: mainTime, Period: period, Periods: periods,
+			MoveLimit: config.Time.MoveLimit}
+	}
+}
+
+// Option to give each player main time, then periods in which the given
+// number of stones must be played
+func CanadianClock(mainTime, period time.Duration, stones int) GameOption {
+	return func(config *GameConfig) {
+		config.Time = TimeControl{System: CanadianTime, MainTime: mainTime, Period: period, Stones: stones,
+			MoveLimit: config.Time.MoveLimit}
+	}
+}
+
+// Option to limit the time any one move may take, with or without a clock
+func MoveTimeLimit(limit time.Duration) GameOption {
+	return func(config *GameConfig) {
+		config.Time.MoveLimit = limit
+	}
+}
+
+// Describes the overtime, as written in the OT property of SGF
+func (control TimeControl) overtime() string {
+	switch control.System {
+	case FischerTime:
+		return "Fischer " + seconds(control.Increment)
+	case ByoYomiTime:
+		return strconv.Itoa(control.Periods) + "x" + seconds(control.Period) + " byo-yomi"
+	case CanadianTime:
+		return strconv.Itoa(control.Stones) + "/" + seconds(control.Period) + " Canadian"
+	}
+	return ""
+}
+
+// Writes a duration in seconds, to the millisecond
+func seconds(duration time.Duration) string {
+	return strconv.FormatFloat(duration.Round(time.Millisecond).Seconds(), 'f', -1, 64)
+}
+
+// Reads a duration written in seconds, as by seconds
+func parseSeconds(text string) (time.Duration, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(value * float64(time.Second)).Round(time.Millisecond), nil
+}
+
+// Reads the clock from the TM and OT properties of SGF, as written by
+// WriteSGF
+// Overtime in any other form is left out, leaving main time only
+func parseTimeControl(tm, ot string) (TimeControl, error) {
+	mainTime, err := parseSeconds(tm)
+	if err != nil || mainTime < 0 {
+		return TimeControl{}, fmt.Errorf("bad SGF main time %q", tm)
+	}
+	control := TimeControl{System: AbsoluteTime, MainTime: mainTime}
+	fields := strings.Fields(ot)
+	if len(fields) != 2 {
+		return control, nil
+	}
+	switch {
+	case fields[0] == "Fischer":
+		if increment, err := parseSeconds(fields[1]); err == nil {
+			control.System, control.Increment = FischerTime, increment
+		}
+	case fields[1] == "byo-yomi":
+		parts := strings.SplitN(fields[0], "x", 2)
+		if len(parts) != 2 {
+			break
+		}
+		periods, err := strconv.Atoi(parts[0])
+		period, periodErr := parseSeconds(parts[1])
+		if err == nil && periodErr == nil {
+			control.System, control.Periods, control.Period = ByoYomiTime, periods, period
+		}
+	case fields[1] == "Canadian":
+		parts := strings.SplitN(fields[0], "/", 2)
+		if len(parts) != 2 {
+			break
+		}
+		stones, err := strconv.Atoi(parts[0])
+		period, periodErr := parseSeconds(parts[1])
+		if err == nil && periodErr == nil {
+			control.System, control.Stones, control.Period = CanadianTime, stones, period
+		}
+	}
+	return control, nil
+}
+
+// One player's clock
+type Clock struct {
+	control TimeControl
+	// Time left, in the main time or the current overtime period
+	Remaining time.Duration
+	// Whether the main time is used up
+	Overtime bool
+	// Byo-yomi periods left, or stones left to play in the Canadian period
+	Periods int
+	Stones  int
+}
+
+// Makes a clock with all its time left
+func newClock(control TimeControl) Clock {
+	clock := Clock{control: control, Remaining: control.MainTime, Periods: control.Periods}
+	if clock.Remaining <= 0 {
+		clock.startOvertime()
+	}
+	return clock
+}
+
+// Moves the clock into overtime, with a fresh period
+func (clock *Clock) startOvertime() {
+	clock.Overtime = true
+	clock.Remaining = clock.control.Period
+	clock.Stones = clock.control.Stones
+}
+
+// Returns the longest the next move may take, zero for no limit
+func (clock *Clock) available() time.Duration {
+	var limit time.Duration
+	switch clock.control.System {
+	case AbsoluteTime, FischerTime:
+		limit = clock.Remaining
+	case ByoYomiTime:
+		// Every period left may be used up
+		limit = clock.Remaining
+		if !clock.Overtime {
+			limit += time.Duration(clock.Periods) * clock.control.Period
+		} else {
+			limit += time.Duration(clock.Periods-1) * clock.control.Period
+		}
+	case CanadianTime:
+		limit = clock.Remaining
+		if !clock.Overtime {
+			limit += clock.control.Period
+		}
+	}
+	if clock.control.MoveLimit > 0 && (limit == 0 || clock.control.MoveLimit < limit) {
+		limit = clock.control.MoveLimit
+	}
+	return limit
+}
+
+// Takes the time used for a move off the clock
+// Returns false if the player ran out of time
+func (clock *Clock) spend(used time.Duration) bool {
+	if clock.control.MoveLimit > 0 && used > clock.control.MoveLimit {
+		return false
+	}
+	switch clock.control.System {
+	case AbsoluteTime:
+		clock.Remaining -= used
+		return clock.Remaining >= 0
+	case FischerTime:
+		clock.Remaining -= used
+		if clock.Remaining < 0 {
+			return false
+		}
+		clock.Remaining += clock.control.Increment
+	case ByoYomiTime:
+		if !clock.Overtime {
+			if used <= clock.Remaining {
+				clock.Remaining -= used
+				return true
+			}
+			used -= clock.Remaining
+			clock.startOvertime()
+		}
+		// Each period used up is lost, and the next move gets a fresh one
+		clock.Periods -= int(used / clock.control.Period)
+		if clock.Periods <= 0 {
+			clock.Periods = 0
+			return false
+		}
+	case CanadianTime:
+		if !clock.Overtime {
+			if used <= clock.Remaining {
+				clock.Remaining -= used
+				return true
+			}
+			used -= clock.Remaining
+			clock.startOvertime()
+		}
+		clock.Remaining -= used
+		if clock.Remaining < 0 {
+			return false
+		}
+		clock.Stones--
+		if clock.Stones <= 0 {
+			clock.startOvertime()
+		}
+	}
+	return true
+}
+
+// Works out how long a move took from the clock after it, as written in
+// the BL and WL properties of SGF, and takes that off the clock
+// Overtime is the OB or OW property, -1 if there was none
+// Time spent in a byo-yomi period, or in a Canadian period the move
+// completes, is not recorded, so is not counted
+func (clock *Clock) spendUntil(remaining time.Duration, overtime int) time.Duration {
+	var used time.Duration
+	switch clock.control.System {
+	case AbsoluteTime:
+		used = clock.Remaining - remaining
+	case FischerTime:
+		used = clock.Remaining + clock.control.Increment - remaining
+	case ByoYomiTime:
+		if overtime < 0 {
+			used = clock.Remaining - remaining
+			break
+		}
+		if !clock.Overtime {
+			used = clock.Remaining
+		}
+		used += time.Duration(clock.Periods-overtime) * clock.control.Period
+	case CanadianTime:
+		if overtime < 0 {
+			used = clock.Remaining - remaining
+			break
+		}
+		period, stones := clock.Remaining, clock.Stones
+		if !clock.Overtime {
+			used, period, stones = clock.Remaining, clock.control.Period, clock.control.Stones
+		}
+		// A move that completes its period starts a fresh one
+		if stones > 1 {
+			used += period - remaining
+		}
+	}
+	if used < 0 {
+		used = 0
+	}
+	clock.spend(used)
+	return used
+}
+
+// Shows the time left, with any overtime
+func (clock Clock) String() string {
+	left := clock.Remaining.Round(time.Second).String()
+	if !clock.Overtime {
+		return left
+	}
+	switch clock.control.System {
+	case ByoYomiTime:
+		return fmt.Sprintf("%d x %s", clock.Periods, clock.control.Period)
+	case CanadianTime:
+		return fmt.Sprintf("%s for %d stones", left, clock.Stones)
+	}
+	return left
+}
+
+// Error for a player running out of time
+type TimeoutError struct {
+	// Whether the player was black
+	Black bool
+	// How long the move took
+	Used time.Duration
+}
+
+func (err *TimeoutError) Error() string {
+	player := "White"
+	if err.Black {
+		player = "Black"
+	}
+	return fmt.Sprintf("%s ran out of time after %s", player, err.Used.Round(time.Millisecond))
+}
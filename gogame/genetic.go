@@ -25,18 +25,19 @@ func makeAutomatonPlayer(data []byte) func(Position) Intersection {
 
 	return func(pos Position) Intersection {
 
-		rwBoard := [SIZE][SIZE]int8{}
-		rwFirst := (SIZE + 1) / 2
-		rwSecond := (SIZE + 1) / 2
+		size := pos.board.size
+		rwBoard := [MAX_SIZE][MAX_SIZE]int8{}
+		rwFirst := (size + 1) / 2
+		rwSecond := (size + 1) / 2
 
 		var state uint8 = 0
 		for i := 0; i < 100; i++ { // For loop limits number of steps
 			if int(state) >= len(data) {
 				return PASS
 			}
-			if rwFirst < 0 || rwFirst >= SIZE || rwSecond < 0 || rwSecond >= SIZE {
-				rwFirst = (SIZE + 1) / 2
-				rwSecond = (SIZE + 1) / 2
+			if rwFirst < 0 || rwFirst >= size || rwSecond < 0 || rwSecond >= size {
+				rwFirst = (size + 1) / 2
+				rwSecond = (size + 1) / 2
 			}
 			lit := [8]bool{} // To contain the bits of the byte of state
 			//read in bits
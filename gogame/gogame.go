@@ -1,19 +1,74 @@
 package gogame
 
 import (
+	"context"
 	"fmt"
-	//"time"
+	"runtime/debug"
+	"time"
 )
 
-// We adopt the convention that Intersection{SIZE, SIZE} represents a pass
-var PASS Intersection = Intersection{SIZE, SIZE}
+// We adopt the convention that Intersection{MAX_SIZE, MAX_SIZE} represents a pass
+// This lies off every board, whatever size is chosen
+var PASS Intersection = Intersection{MAX_SIZE, MAX_SIZE}
+
+// A player returns RESIGN to give up the game, which is then lost
+var RESIGN Intersection = Intersection{MAX_SIZE, MAX_SIZE + 1}
+
+// Settings for a game, fixed when the game is made
+type GameConfig struct {
+	// Number of rows and columns on the board
+	Size uint8
+	// The rules the game is played under
+	Rules Ruleset
+	// Points given to white, the standard komi of the rules if not set
+	Komi    float64
+	komiSet bool
+	// Number of handicap stones given to black
+	// Free handicap stones are placed by the black player
+	Handicap     int
+	FreeHandicap bool
+	// Names of the players, as written in game records
+	BlackName string
+	WhiteName string
+	// The game clocks, none by default
+	Time TimeControl
+}
+
+// An option passed to MakeGame, which changes the game config
+type GameOption func(*GameConfig)
+
+// Option to play on a board of the given size
+func BoardSize(size uint8) GameOption {
+	return func(config *GameConfig) {
+		config.Size = size
+	}
+}
 
 type Game struct {
 	// A slice of all boards so far in the game, starting with empty board
 	BoardList []Board
-	// Functions that are the players
-	BlackPlayer func(Position) Intersection
-	WhitePlayer func(Position) Intersection
+	// The moves so far, the kth leading from the kth board to the next
+	Moves []Move
+	// Moves taken back by Undo, the last taken back last
+	undone []Move
+	// The players
+	BlackPlayer Player
+	WhitePlayer Player
+	// The settings the game was made with
+	Config GameConfig
+	// Zobrist keys of every position so far, and how often each occured,
+	// for the ko rule
+	seen map[uint64]int
+	// Whether white makes the first move, as in handicap games
+	whiteFirst bool
+	// The first player to panic when told of the new game, and its panic
+	newGameLoser Color
+	newGameErr   error
+	// How the game ended, nil until it has been played
+	Result *GameResult
+	// Each player's clock
+	BlackClock Clock
+	WhiteClock Clock
 }
 
 // Makes the current position of the game.
@@ -23,153 +78,392 @@ func (game *Game) makeCurrentPosition() Position {
 	move := len(game.BoardList)
 	// The board is the last element of the BoardList slice
 	currentPostion.board = game.BoardList[move-1]
-	// The player to move is black iff the boardlist has odd length
-	currentPostion.blacksTurn = (move%2 == 1)
-	// We must find all illegal intersections - occupied, suicide and ko
+	currentPostion.blacksTurn = game.blacksTurnAt(move - 1)
+	// What the position needs to be played on by itself
+	currentPostion.rules = game.Config.Rules
+	if move >= 2 {
+		currentPostion.previous = game.BoardList[move-2]
+	}
+	currentPostion.history = make([]uint64, move)
+	for k := range game.BoardList {
+		currentPostion.history[k] = game.positionKey(&game.BoardList[k], game.blacksTurnAt(k))
+	}
+	currentPostion.captures[0], currentPostion.captures[1] = game.prisoners()
+	currentPostion.markIllegal(game.Config.Rules.Suicide, func(board *Board) bool {
+		return game.breaksKo(board, !currentPostion.blacksTurn)
+	})
+	return currentPostion
+}
+
+// Marks all illegal intersections of the position - occupied, suicide and ko
+// breaksKo says whether the ko rule forbids reaching a board
+func (pos *Position) markIllegal(suicide bool, breaksKo func(*Board) bool) {
+	size := pos.board.size
 	// Loop through all intersections
-	for i := 0; uint8(i) < SIZE; i++ {
-		for j := 0; uint8(j) < SIZE; j++ {
+	for i := 0; uint8(i) < size; i++ {
+		for j := 0; uint8(j) < size; j++ {
 			var intn Intersection = Intersection{uint8(i), uint8(j)}
 			// If empty, create a copy of the board and make a move there
-			if currentPostion.board.isEmpty(intn) {
-				tempBoard := currentPostion.board
-				if currentPostion.blacksTurn {
+			if pos.board.isEmpty(intn) {
+				tempBoard := pos.board
+				if pos.blacksTurn {
 					tempBoard.playBlackStone(intn)
 				} else {
 					tempBoard.playWhiteStone(intn)
 				}
 				// If the intersection is now empty, suicide
+				// Only allowed by some rules, and never for a single stone
 				if tempBoard.isEmpty(intn) {
-					currentPostion.setIllegal(intn)
-					continue
-				}
-				// If the board state matches another, ko
-				for moveIt := move - 1; moveIt >= 0; moveIt-- {
-					if tempBoard == game.BoardList[moveIt] {
-						currentPostion.setIllegal(intn)
-						break
+					if !suicide || tempBoard == pos.board {
+						pos.setIllegal(intn)
+						continue
 					}
 				}
+				// If the board state is forbidden by the ko rule, ko
+				if breaksKo(&tempBoard) {
+					pos.setIllegal(intn)
+				}
 			} else {
 				// Intersection nonempty, so illegal
-				currentPostion.setIllegal(intn)
+				pos.setIllegal(intn)
 			}
 		}
 	}
-	return currentPostion
 }
 
+// Returns whether black is to move on the kth board of the game
+// Black moves first unless there is a handicap, so black is usually
+// to move on the even boards
+func (game *Game) blacksTurnAt(k int) bool {
+	return (k%2 == 0) != game.whiteFirst
+}
+
+// Asks if the game has ended with two passes in a row
+// Some rules also need white to have passed last
 func (game *Game) gameOver() bool {
-	move := len(game.BoardList)
-	if move <= 2 {
+	move := len(game.Moves)
+	if move < 2 {
+		return false
+	}
+	if game.Config.Rules.WhitePassesLast && game.Moves[move-1].Color != White {
 		return false
 	}
-	previousPass := game.BoardList[move-2] == game.BoardList[move-3]
-	lastPass := game.BoardList[move-1] == game.BoardList[move-2]
-	return lastPass && previousPass
+	return game.Moves[move-1].Point == PASS && game.Moves[move-2].Point == PASS
 }
 
 // Prints out the record of the game, which has been played.
 func (game *Game) PrintGame() {
 
 	for i := 0; i < len(game.BoardList); i++ {
-		fmt.Printf("Move number %d:\n", i)
+		if i == 0 {
+			fmt.Printf("Move number %d:\n", i)
+		} else {
+			fmt.Printf("Move number %d, %s:\n", i, game.Moves[i-1])
+		}
 		game.BoardList[i].PrintOut()
 		fmt.Println()
 	}
-	move := len(game.BoardList)
-	// The board is the last element of the BoardList slice
-	fmt.Println("Chinese Scoring:")
-	blackScore, whiteScore := game.BoardList[move-1].chineseScoring()
-	fmt.Printf("Black's score is: %d\n", blackScore)
-	fmt.Printf("White's score is: %d\n", whiteScore)
+	fmt.Printf("Scoring under %s rules, komi %.1f:\n", game.Config.Rules.Name, game.Config.Komi)
+	blackScore, whiteScore := game.score()
+	fmt.Printf("Black's score is: %.1f\n", blackScore)
+	fmt.Printf("White's score is: %.1f\n", whiteScore)
+	if game.Result != nil {
+		game.Result.PrintOut()
+	}
 	fmt.Println()
 }
 
-// Plays a single turn, returns true if game ended
-func (game *Game) playTurn() {
+// Error for a player choosing an illegal move
+type IllegalMoveError struct {
+	// Whether the offending player was black
+	Black bool
+	Move  Intersection
+}
+
+func (err *IllegalMoveError) Error() string {
+	player := "White"
+	if err.Black {
+		player = "Black"
+	}
+	return fmt.Sprintf("%s played illegal move %s", player, err.Move)
+}
+
+// Returns the color of the player to move
+func (game *Game) toMove() Color {
+	return colorOf(game.blacksTurnAt(len(game.BoardList) - 1))
+}
+
+// A move chosen by a player, or the error it gave instead
+type genMoveReply struct {
+	move Intersection
+	err  error
+}
+
+// Error for a player that panicked
+type PanicError struct {
+	// The value the player panicked with
+	Value interface{}
+	// The stack of the player when it panicked
+	Stack []byte
+}
+
+func (err *PanicError) Error() string {
+	return fmt.Sprintf("player panicked: %v", err.Value)
+}
+
+// Makes a call on a player, turning a panic into a PanicError
+func safeCall(call func()) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			err = &PanicError{value, debug.Stack()}
+		}
+	}()
+	call()
+	return nil
+}
+
+// Tells both players of something, black first, turning a panic into a
+// PanicError
+// Returns the color of the first player to panic and its error, or Empty
+// and nil
+func (game *Game) tellPlayers(tell func(Player)) (Color, error) {
+	loser, err := Empty, error(nil)
+	if blackErr := safeCall(func() { tell(game.BlackPlayer) }); blackErr != nil {
+		loser, err = Black, blackErr
+	}
+	if whiteErr := safeCall(func() { tell(game.WhitePlayer) }); whiteErr != nil && err == nil {
+		loser, err = White, whiteErr
+	}
+	return loser, err
+}
+
+// Asks the player for a move, turning a panic into a PanicError
+func safeGenMove(ctx context.Context, player Player, pos Position) (Intersection, error) {
+	var move Intersection
+	var err error
+	if panicErr := safeCall(func() { move, err = player.GenMove(ctx, pos) }); panicErr != nil {
+		return PASS, panicErr
+	}
+	return move, err
+}
+
+// Asks the player for a move, giving it until the time available
+// Zero time available is no limit
+// A player that overruns is not waited for, and is left running
+func genMoveWithin(player Player, pos Position, available time.Duration) (Intersection, error) {
+	if available <= 0 {
+		return safeGenMove(context.Background(), player, pos)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), available)
+	defer cancel()
+	replies := make(chan genMoveReply, 1)
+	go func() {
+		move, err := safeGenMove(ctx, player, pos)
+		replies <- genMoveReply{move, err}
+	}()
+	select {
+	case reply := <-replies:
+		return reply.move, reply.err
+	case <-ctx.Done():
+		return PASS, ctx.Err()
+	}
+}
+
+// Plays a single turn, and returns the move chosen
+// Returns an error if the player chose an illegal move or ran out of
+// time, and the move is not played
+// Resigning is not played either
+// The players are not told of the move
+func (game *Game) playTurn() (Intersection, error) {
 	currentPosition := game.makeCurrentPosition()
-	// We now get the move from the player
+	// We now get the move from the player, on their clock
+	player := game.WhitePlayer
+	clock := &game.WhiteClock
 	if currentPosition.blacksTurn {
-		// Get blacks choice of move
-		blacksMove := game.BlackPlayer(currentPosition)
-		// Check legality of move
-		if !currentPosition.isLegal(blacksMove) {
-			panic("Illegal Move\n")
-		}
-		if blacksMove != PASS {
-			currentPosition.board.playBlackStone(blacksMove)
-		}
-	} else {
-		// Get whites choice of move
-		whitesMove := game.WhitePlayer(currentPosition)
-		// Check legality of move
-		if !currentPosition.isLegal(whitesMove) {
-			panic("Illegal Move\n")
-		}
-		if whitesMove != PASS {
-			currentPosition.board.playWhiteStone(whitesMove)
+		player = game.BlackPlayer
+		clock = &game.BlackClock
+	}
+	start := time.Now()
+	move, err := genMoveWithin(player, currentPosition, clock.available())
+	used := time.Since(start)
+	if !clock.spend(used) || err == context.DeadlineExceeded {
+		return move, &TimeoutError{currentPosition.blacksTurn, used}
+	}
+	if err != nil {
+		return move, err
+	}
+	// Take back the player's last move and the reply, so they move again
+	// with the clocks as they were before them
+	if move == UNDO {
+		if len(game.Moves) < 2 {
+			return move, &IllegalMoveError{currentPosition.blacksTurn, move}
 		}
+		game.takeBack()
+		game.takeBack()
+		return move, nil
+	}
+	// Check legality of move
+	if !currentPosition.isLegal(move) {
+		return move, &IllegalMoveError{currentPosition.blacksTurn, move}
 	}
-	game.BoardList = append(game.BoardList, currentPosition.board)
+	if move == RESIGN {
+		return move, nil
+	}
+	if err := game.appendMove(move); err != nil {
+		return move, err
+	}
+	game.Moves[len(game.Moves)-1].Time = used
+	return move, nil
 }
 
+// Longest game played, in boards, before it is stopped and scored
+const MOVE_LIMIT = 1000
+
 // Activates the game,
 // Keeps playing until two passes in a row
-// Does not have ko or suicide rules, or komi
-func (game *Game) PlayGame() (int, int) {
-	for !game.gameOver() {
-		game.playTurn()
-		if len(game.BoardList) > 1000 {
-			fmt.Println("Game ends on 1000 move rule")
+// Ko, suicide and scoring follow the rules of the game
+// If a player resigns, makes an illegal move, runs out of time, panics
+// or fails to choose a move the game stops, and the opponent wins with
+// the whole board, the loser getting nothing
+// A player that panicked when told of the new game, or panics when told
+// of a move, forfeits in the same way
+// A player returning UNDO takes back their last move and the reply, and
+// the clocks go back to before them
+// The result is also kept in the game, and given to both players
+// A player that panics when given the result forfeits the game after all,
+// though the other has been given the result already
+func (game *Game) PlayGame() GameResult {
+	var result GameResult
+	for {
+		if game.newGameErr != nil {
+			result = game.lossResult(game.newGameLoser, Forfeit, game.newGameErr)
+			break
+		}
+		if game.gameOver() {
+			result = game.scoredResult(TwoPasses)
+			break
+		}
+		mover := game.toMove()
+		move, err := game.playTurn()
+		if _, ok := err.(*TimeoutError); ok {
+			result = game.lossResult(mover, Timeout, err)
+			break
+		}
+		if err != nil {
+			result = game.lossResult(mover, Forfeit, err)
+			break
+		}
+		if move == RESIGN {
+			result = game.lossResult(mover, Resignation, nil)
+			break
+		}
+		// Players are shown the move, or the game again once moves are
+		// taken back
+		tell := func(player Player) { player.Observe(mover, move) }
+		if move == UNDO {
+			tell = game.replayTo
+		}
+		if loser, err := game.tellPlayers(tell); err != nil {
+			result = game.lossResult(loser, Forfeit, err)
+			break
+		}
+		if len(game.BoardList) > MOVE_LIMIT {
+			result = game.scoredResult(MoveLimit)
 			break
 		}
 	}
-	// The game is over
-	move := len(game.BoardList)
-	// The board is the last element of the BoardList slice
-	blackScore, whiteScore := game.BoardList[move-1].chineseScoring()
-	return blackScore, whiteScore
+	game.Result = &result
+	if loser, err := game.tellPlayers(func(player Player) { player.GameOver(result) }); err != nil {
+		result = game.lossResult(loser, Forfeit, err)
+		game.Result = &result
+	}
+	return result
+}
+
+// Plays a move for the player to move, and adds it to the game
+// Only checks that the intersection is empty, as records may come from
+// games under other rules
+// The moves taken back by Undo can no longer be redone
+func (game *Game) appendMove(intn Intersection) error {
+	if err := game.addMove(intn); err != nil {
+		return err
+	}
+	game.undone = nil
+	return nil
 }
 
-func MakeGame(blackPlayer, whitePlayer func(Position) Intersection) Game {
+// Makes a game between the two players, starting from an empty board
+// or from black's handicap stones
+// Options change the settings, by default the board is DEFAULT_SIZE
+// and the game is played under Chinese rules with their komi
+// Handicap games have a komi of 0.5 unless it is set
+// Players are named in the record after themselves unless names are set,
+// and are told of the new game before any free handicap is placed
+// A player that panics when told forfeits the game once it is played
+// Panics if the options give a board size or handicap out of range
+func MakeGame(blackPlayer, whitePlayer Player, options ...GameOption) Game {
 	var game Game
-	game.BoardList = make([]Board, 1, 1)
+	game.Config = GameConfig{Size: DEFAULT_SIZE, Rules: ChineseRules}
+	for _, option := range options {
+		option(&game.Config)
+	}
+	if !game.Config.komiSet {
+		game.Config.Komi = game.Config.Rules.Komi
+		if game.Config.Handicap > 0 {
+			game.Config.Komi = 0.5
+		}
+	}
+	if game.Config.BlackName == "" {
+		game.Config.BlackName = blackPlayer.Name()
+	}
+	if game.Config.WhiteName == "" {
+		game.Config.WhiteName = whitePlayer.Name()
+	}
 	game.BlackPlayer = blackPlayer
 	game.WhitePlayer = whitePlayer
+	game.newGameLoser, game.newGameErr = game.tellPlayers(func(player Player) { player.NewGame(game.Config) })
+	game.BlackClock = newClock(game.Config.Time)
+	game.WhiteClock = newClock(game.Config.Time)
+	game.BoardList = make([]Board, 1, 1)
+	game.BoardList[0] = newBoard(game.Config.Size)
+	game.placeHandicap()
+	game.whiteFirst = game.Config.Handicap >= 2
+	game.seen = make(map[uint64]int)
+	game.recordPosition(&game.BoardList[0], game.blacksTurnAt(0))
 	return game
 }
 
-// Scores the came using chinese rules and prints out results
-// Prints out scores, without komi, for black and white
-// Prints out a board showing black and white area
-// Returns black and white scores
-func (board *Board) chineseScoring() (int, int) {
-
-	// compute the area of each player
-	var scoreBoard Board = *board
-	blackScore := 0
-	whiteScore := 0
-	// Loop through all intersections
-	for i := 0; uint8(i) < SIZE; i++ {
-		for j := 0; uint8(j) < SIZE; j++ {
-			var intn Intersection = Intersection{uint8(i), uint8(j)}
-			// If empty, see if it can be filled
-			if scoreBoard.isEmpty(intn) {
-				if scoreBoard.isBlackTerritory(intn) {
-					scoreBoard.fillSpaceBlack(intn)
-				} else if scoreBoard.isWhiteTerritory(intn) {
-					scoreBoard.fillSpaceWhite(intn)
-				}
-			}
-			// If now nonempty, add to score
-			if scoreBoard.isBlackStone(intn) {
-				blackScore++
-			}
-			if scoreBoard.isWhiteStone(intn) {
-				whiteScore++
-			}
+// Fills in the territory of each color on a copy of the board
+// Areas secured by pass-alive chains are filled first, taking off any
+// dead stones left in them
+// Empty points reached by both colors are left empty
+func (board *Board) ownership() Board {
+	var cleared Board = *board
+	_, blackArea := board.passAlive(true)
+	_, whiteArea := board.passAlive(false)
+	cleared.fillMaskBlack(&blackArea)
+	cleared.fillMaskWhite(&whiteArea)
+	var scoreBoard Board = cleared
+	// Each empty region is found once, and filled if it is territory
+	empty := cleared.emptyMask()
+	for !empty.isZero() {
+		region := flood(pointMask(empty.first()), empty, board.size)
+		if !cleared.touches(&region, false) {
+			scoreBoard.fillMaskBlack(&region)
+		} else if !cleared.touches(&region, true) {
+			scoreBoard.fillMaskWhite(&region)
+		}
+		for i := range empty {
+			empty[i] &^= region[i]
 		}
 	}
-	return blackScore, whiteScore
+	return scoreBoard
+}
+
+// Scores the came using chinese rules
+// Returns black and white area, without komi
+func (board *Board) chineseScoring() (int, int) {
+	// compute the area of each player
+	scoreBoard := board.ownership()
+	return scoreBoard.countStones()
 }
@@ -0,0 +1,50 @@
+package gogame
+
+import "testing"
+
+// A passing player that panics when the named method is called on it
+type panickyPlayer struct {
+	Player
+	method string
+}
+
+func (player *panickyPlayer) NewGame(config GameConfig) {
+	if player.method == "NewGame" {
+		panic("NewGame")
+	}
+}
+
+func (player *panickyPlayer) Observe(color Color, move Intersection) {
+	if player.method == "Observe" {
+		panic("Observe")
+	}
+}
+
+func (player *panickyPlayer) GameOver(result GameResult) {
+	if player.method == "GameOver" {
+		panic("GameOver")
+	}
+}
+
+func TestPanicForfeitsGame(t *testing.T) {
+	passer := func(pos Position) Intersection { return PASS }
+	for _, method := range []string{"NewGame", "Observe", "GameOver"} {
+		for _, loser := range []Color{Black, White} {
+			black := Player(&panickyPlayer{FuncPlayer("black", passer), ""})
+			white := Player(&panickyPlayer{FuncPlayer("white", passer), ""})
+			if loser == Black {
+				black = &panickyPlayer{FuncPlayer("black", passer), method}
+			} else {
+				white = &panickyPlayer{FuncPlayer("white", passer), method}
+			}
+			game := MakeGame(black, white, BoardSize(9))
+			result := game.PlayGame()
+			if result.Winner != loser.Opponent() || result.Reason != Forfeit {
+				t.Errorf("%s panicking in %s: winner %s by %s, want a forfeit", loser, method, result.Winner, result.Reason)
+			}
+			if _, ok := result.Err.(*PanicError); !ok {
+				t.Errorf("%s panicking in %s: error %v, want a panic", loser, method, result.Err)
+			}
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package gogame
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Column letters of GTP vertices, which skip I
+const gtpColumns string = "ABCDEFGHJKLMNOPQRSTUVWXYZ"
+
+// Returns the GTP vertex of an intersection, such as D4 or pass,
+// or resign as answered to genmove
+// Columns are lettered from the left, rows numbered from the bottom
+func gtpVertex(intn Intersection, size uint8) string {
+	if intn == PASS {
+		return "pass"
+	}
+	if intn == RESIGN {
+		return "resign"
+	}
+	return string(gtpColumns[intn.y]) + strconv.Itoa(int(size-intn.x))
+}
+
+// Reads a GTP vertex on a board of the given size
+func parseGTPVertex(vertex string, size uint8) (Intersection, error) {
+	vertex = strings.ToUpper(vertex)
+	if vertex == "PASS" {
+		return PASS, nil
+	}
+	if len(vertex) < 2 {
+		return PASS, fmt.Errorf("invalid vertex %s", vertex)
+	}
+	column := strings.IndexByte(gtpColumns, vertex[0])
+	row, err := strconv.Atoi(vertex[1:])
+	if column < 0 || err != nil {
+		return PASS, fmt.Errorf("invalid vertex %s", vertex)
+	}
+	if column >= int(size) || row < 1 || row > int(size) {
+		return PASS, fmt.Errorf("vertex %s is off the board", vertex)
+	}
+	return Intersection{size - uint8(row), uint8(column)}, nil
+}
+
+// Reads a GTP color, returning true for black
+func parseGTPColor(color string) (bool, error) {
+	switch strings.ToLower(color) {
+	case "b", "black":
+		return true, nil
+	case "w", "white":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid color %s", color)
+}
+
+// An engine speaking the Go Text Protocol, version 2
+// It lets a player play through GTP controllers such as GoGui, Sabaki
+// and twogtp
+type GTPEngine struct {
+	// Name and version reported to the controller
+	Name    string
+	Version string
+	player  Player
+	options []GameOption
+	game    Game
+}
+
+// The commands a GTPEngine knows, as listed to the controller
+var gtpCommands = []string{
+	"protocol_version", "name", "version", "known_command", "list_commands",
+	"boardsize", "clear_board", "komi", "play", "genmove", "undo",
+	"final_score", "showboard", "quit",
+}
+
+// Makes a GTP engine for the given player, named after it
+// Options set up the games it plays, boardsize and komi commands
+// change them
+func NewGTPEngine(player Player, options ...GameOption) *GTPEngine {
+	engine := &GTPEngine{Name: player.Name(), Version: "1", player: player}
+	engine.options = options
+	engine.clearBoard()
+	return engine
+}
+
+// Starts a new game with the engine's options
+// Returns the error of a player that panicked on sitting down to it
+func (engine *GTPEngine) clearBoard() error {
+	engine.game = MakeGame(engine.player, engine.player, engine.options...)
+	return engine.game.newGameErr
+}
+
+// Shows the player a move, turning a panic into a PanicError
+func (engine *GTPEngine) observe(color Color, intn Intersection) error {
+	return safeCall(func() { engine.player.Observe(color, intn) })
+}
+
+// Reads commands from in and writes responses to out, until the
+// quit command or the end of the input
+func (engine *GTPEngine) Run(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	writer := bufio.NewWriter(out)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Remove comments and control characters
+		if hash := strings.IndexByte(line, '#'); hash >= 0 {
+			line = line[:hash]
+		}
+		line = strings.Map(func(r rune) rune {
+			if r == '\t' {
+				return ' '
+			}
+			if r < 32 || r == 127 {
+				return -1
+			}
+			return r
+		}, line)
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		// Commands may start with a numeric id, echoed in the response
+		id := ""
+		if _, err := strconv.Atoi(fields[0]); err == nil {
+			id = fields[0]
+			fields = fields[1:]
+			if len(fields) == 0 {
+				continue
+			}
+		}
+		response, err := engine.execute(fields[0], fields[1:])
+		if err != nil {
+			fmt.Fprintf(writer, "?%s %s\n\n", id, err)
+		} else {
+			fmt.Fprintf(writer, "=%s %s\n\n", id, response)
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		if fields[0] == "quit" {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// Carries out a single command, returning the response
+func (engine *GTPEngine) execute(command string, args []string) (string, error) {
+	game := &engine.game
+	size := game.Config.Size
+	switch command {
+	case "protocol_version":
+		return "2", nil
+	case "name":
+		return engine.Name, nil
+	case "version":
+		return engine.Version, nil
+	case "known_command":
+		if len(args) < 1 {
+			return "", errors.New("syntax error")
+		}
+		for _, known := range gtpCommands {
+			if known == args[0] {
+				return "true", nil
+			}
+		}
+		return "false", nil
+	case "list_commands":
+		return strings.Join(gtpCommands, "\n"), nil
+	case "quit":
+		return "", nil
+	case "boardsize":
+		if len(args) < 1 {
+			return "", errors.New("syntax error")
+		}
+		newSize, err := strconv.Atoi(args[0])
+		if err != nil {
+			return "", errors.New("syntax error")
+		}
+		if newSize < int(MIN_SIZE) || newSize > int(MAX_SIZE) {
+			return "", errors.New("unacceptable size")
+		}
+		engine.options = append(engine.options, BoardSize(uint8(newSize)))
+		return "", engine.clearBoard()
+	case "clear_board":
+		return "", engine.clearBoard()
+	case "komi":
+		if len(args) < 1 {
+			return "", errors.New("syntax error")
+		}
+		komi, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return "", errors.New("syntax error")
+		}
+		engine.options = append(engine.options, Komi(komi))
+		game.Config.Komi = komi
+		// Komi is set before the first move, so the player is told as
+		// if sitting down again
+		if len(game.Moves) == 0 {
+			return "", safeCall(func() { engine.player.NewGame(game.Config) })
+		}
+		return "", nil
+	case "play":
+		if len(args) < 2 {
+			return "", errors.New("syntax error")
+		}
+		black, err := parseGTPColor(args[0])
+		if err != nil {
+			return "", errors.New("syntax error")
+		}
+		intn, err := parseGTPVertex(args[1], size)
+		if err != nil {
+			return "", errors.New("syntax error")
+		}
+		passed := engine.passUntilTurn(black)
+		pos := game.makeCurrentPosition()
+		if !pos.isLegal(intn) {
+			// Take back any pass made to give the player the move, which
+			// the player has not been shown
+			if passed {
+				game.takeBack()
+			}
+			return "", errors.New("illegal move")
+		}
+		if passed {
+			if err := engine.observe(colorOf(!black), PASS); err != nil {
+				return "", err
+			}
+		}
+		game.appendMove(intn)
+		return "", engine.observe(colorOf(black), intn)
+	case "genmove":
+		if len(args) < 1 {
+			return "", errors.New("syntax error")
+		}
+		black, err := parseGTPColor(args[0])
+		if err != nil {
+			return "", errors.New("syntax error")
+		}
+		if engine.passUntilTurn(black) {
+			if err := engine.observe(colorOf(!black), PASS); err != nil {
+				return "", err
+			}
+		}
+		pos := game.makeCurrentPosition()
+		intn, err := safeGenMove(context.Background(), engine.player, pos)
+		if err != nil {
+			return "", err
+		}
+		if intn == UNDO {
+			return "", errors.New("player cannot undo when asked for a move")
+		}
+		if !pos.isLegal(intn) {
+			return "", fmt.Errorf("player chose illegal move %s", gtpVertex(intn, size))
+		}
+		// A resignation leaves the board as it is
+		if intn != RESIGN {
+			game.appendMove(intn)
+			if err := engine.observe(colorOf(black), intn); err != nil {
+				return "", err
+			}
+		}
+		return gtpVertex(intn, size), nil
+	case "undo":
+		if !game.Undo() {
+			return "", errors.New("cannot undo")
+		}
+		return "", safeCall(func() { game.replayTo(engine.player) })
+	case "final_score":
+		return game.resultString(), nil
+	case "showboard":
+		return "\n" + strings.TrimRight(game.BoardList[len(game.BoardList)-1].String(), "\n"), nil
+	}
+	return "", errors.New("unknown command")
+}
+
+// Gives the move to the given player, passing for the other if needed
+// GTP lets the controller play several moves of one color in a row
+// Returns whether a pass was made, which the player is yet to be shown
+func (engine *GTPEngine) passUntilTurn(black bool) bool {
+	game := &engine.game
+	if game.blacksTurnAt(len(game.BoardList)-1) == black {
+		return false
+	}
+	game.appendMove(PASS)
+	return true
+}
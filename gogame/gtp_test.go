@@ -0,0 +1,56 @@
+package gogame
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Runs the commands through an engine for the player, and returns the
+// responses
+func runGTP(t *testing.T, player Player, commands ...string) []string {
+	engine := NewGTPEngine(player, BoardSize(9))
+	var out bytes.Buffer
+	if err := engine.Run(strings.NewReader(strings.Join(commands, "\n")), &out); err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(out.String(), "\n\n"), "\n\n")
+}
+
+func TestGTPEngineShowsPlayerTheGame(t *testing.T) {
+	player := &scriptedPlayer{}
+	responses := runGTP(t, player, "play b D4", "play b D4", "play w E5", "play b C3", "undo")
+	want := []string{"= ", "? illegal move", "= ", "= ", "= "}
+	if !reflect.DeepEqual(responses, want) {
+		t.Errorf("got responses %q, want %q", responses, want)
+	}
+	// The pass made to let black play D4 again was taken back unseen, and
+	// C3 taken back by undo
+	shown := []Move{
+		{Color: Black, Point: Intersection{5, 3}},
+		{Color: White, Point: Intersection{4, 4}},
+	}
+	if !reflect.DeepEqual(player.observed, shown) {
+		t.Errorf("player was shown %v, want %v", player.observed, shown)
+	}
+}
+
+func TestGTPEnginePanickyPlayer(t *testing.T) {
+	passer := func(pos Position) Intersection { return PASS }
+	for method, commands := range map[string][]string{
+		"NewGame": {"clear_board", "boardsize 9", "komi 6.5"},
+		"Observe": {"play b D4", "genmove w", "undo"},
+	} {
+		player := &panickyPlayer{FuncPlayer("panicky", passer), method}
+		responses := runGTP(t, player, append(commands, "name")...)
+		for k, command := range commands {
+			if !strings.HasPrefix(responses[k], "? player panicked") {
+				t.Errorf("%s panicking on %q: got response %q", method, command, responses[k])
+			}
+		}
+		if last := responses[len(responses)-1]; last != "= panicky" {
+			t.Errorf("%s panicking: engine then gave %q, want it still running", method, last)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package gogame
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A controller for an external GTP engine, run as a subprocess
+// It is a Player, so can sit in a game like any other player
+// Once the engine has timed out it is killed, and every move fails
+type GTPClient struct {
+	// Longest wait for any response, zero to wait forever
+	Timeout time.Duration
+	// Komi sent to the engine whenever its board is cleared
+	Komi float64
+
+	name  string
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan string
+	dead  bool
+	// The board the engine has, and whose turn it thinks it is
+	board            Board
+	engineBlacksTurn bool
+}
+
+// Starts the engine given by the command and its arguments
+func NewGTPClient(timeout time.Duration, command string, args ...string) (*GTPClient, error) {
+	client := &GTPClient{Timeout: timeout}
+	client.cmd = exec.Command(command, args...)
+	stdin, err := client.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := client.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := client.cmd.Start(); err != nil {
+		return nil, err
+	}
+	client.stdin = stdin
+	// Read lines in the background, so reads can time out
+	client.lines = make(chan string, 16)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			client.lines <- scanner.Text()
+		}
+		close(client.lines)
+	}()
+	if _, err := client.Send("protocol_version"); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// Sends a command to the engine, and returns its response
+// A failure response from the engine is returned as an error
+func (client *GTPClient) Send(command string) (string, error) {
+	return client.sendContext(context.Background(), command)
+}
+
+// Sends a command as Send, also giving up when the context is done
+// The engine is killed when it is given up on, as its response would
+// otherwise be read as the response to the next command
+func (client *GTPClient) sendContext(ctx context.Context, command string) (string, error) {
+	if client.dead {
+		return "", errors.New("GTP engine is not running")
+	}
+	if _, err := fmt.Fprintln(client.stdin, command); err != nil {
+		client.dead = true
+		return "", err
+	}
+	var timeout <-chan time.Time
+	if client.Timeout > 0 {
+		timer := time.NewTimer(client.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	response := []string{}
+	for {
+		select {
+		case line, ok := <-client.lines:
+			if !ok {
+				client.dead = true
+				return "", fmt.Errorf("GTP engine exited during %q", command)
+			}
+			line = strings.TrimRight(line, "\r")
+			if line == "" {
+				if len(response) == 0 {
+					// Blank lines before a response are ignored
+					continue
+				}
+				return parseGTPResponse(response, command)
+			}
+			response = append(response, line)
+		case <-timeout:
+			client.kill()
+			return "", fmt.Errorf("GTP engine timed out on %q", command)
+		case <-ctx.Done():
+			client.kill()
+			return "", fmt.Errorf("GTP engine ran out of time on %q", command)
+		}
+	}
+}
+
+// Splits a response into its status and text
+func parseGTPResponse(lines []string, command string) (string, error) {
+	first := lines[0]
+	if len(first) == 0 || (first[0] != '=' && first[0] != '?') {
+		return "", fmt.Errorf("bad GTP response %q to %q", first, command)
+	}
+	// Remove the status and any id
+	text := strings.TrimLeft(first[1:], "0123456789")
+	text = strings.TrimSpace(strings.Join(append([]string{text}, lines[1:]...), "\n"))
+	if first[0] == '?' {
+		return "", fmt.Errorf("GTP engine rejected %q: %s", command, text)
+	}
+	return text, nil
+}
+
+// Kills the engine, after which every command fails
+func (client *GTPClient) kill() {
+	client.dead = true
+	if client.cmd.Process != nil {
+		client.cmd.Process.Kill()
+	}
+}
+
+// Asks the engine to quit, and waits for it to exit
+func (client *GTPClient) Close() error {
+	if !client.dead {
+		client.Send("quit")
+		client.dead = true
+	}
+	client.stdin.Close()
+	return client.cmd.Wait()
+}
+
+// Clears the engine's board, and sets it up with the stones of the board
+// Stones are played in any order, which never captures in a legal board
+func (client *GTPClient) setUp(board Board) error {
+	if client.board.size != board.size {
+		if _, err := client.Send("boardsize " + strconv.Itoa(int(board.size))); err != nil {
+			return err
+		}
+	}
+	if _, err := client.Send("clear_board"); err != nil {
+		return err
+	}
+	if _, err := client.Send("komi " + strconv.FormatFloat(client.Komi, 'f', -1, 64)); err != nil {
+		return err
+	}
+	client.board = newBoard(board.size)
+	client.engineBlacksTurn = true
+	for i := uint8(0); i < board.size; i++ {
+		for j := uint8(0); j < board.size; j++ {
+			intn := Intersection{i, j}
+			if board.isBlackStone(intn) {
+				if err := client.play(true, intn); err != nil {
+					return err
+				}
+			} else if board.isWhiteStone(intn) {
+				if err := client.play(false, intn); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Tells the engine a move was played, and plays it on the client's board
+func (client *GTPClient) play(black bool, intn Intersection) error {
+	color := "w"
+	if black {
+		color = "b"
+	}
+	if _, err := client.Send("play " + color + " " + gtpVertex(intn, client.board.size)); err != nil {
+		return err
+	}
+	if intn != PASS {
+		if black {
+			client.board.playBlackStone(intn)
+		} else {
+			client.board.playWhiteStone(intn)
+		}
+	}
+	client.engineBlacksTurn = !black
+	return nil
+}
+
+// Brings the engine's board to the board of the position
+// A single opponent move or pass is sent as a play, anything else sets
+// the board up from scratch
+func (client *GTPClient) sync(pos *Position) error {
+	opponentBlack := !pos.blacksTurn
+	if client.board.size == pos.board.size {
+		if client.board == pos.board {
+			if client.engineBlacksTurn == pos.blacksTurn {
+				return nil
+			}
+			return client.play(opponentBlack, PASS)
+		}
+		if client.engineBlacksTurn == opponentBlack {
+			for i := uint8(0); i < pos.board.size; i++ {
+				for j := uint8(0); j < pos.board.size; j++ {
+					intn := Intersection{i, j}
+					if !client.board.isEmpty(intn) {
+						continue
+					}
+					tempBoard := client.board
+					if opponentBlack {
+						tempBoard.playBlackStone(intn)
+					} else {
+						tempBoard.playWhiteStone(intn)
+					}
+					if tempBoard == pos.board {
+						if client.play(opponentBlack, intn) == nil {
+							return nil
+						}
+						// The engine thinks the move is illegal, so
+						// set the board up instead
+						return client.setUp(pos.board)
+					}
+				}
+			}
+		}
+	}
+	return client.setUp(pos.board)
+}
+
+// Returns the name the engine gives itself, asked for once
+func (client *GTPClient) Name() string {
+	if client.name == "" {
+		client.name = "GTP engine"
+		if name, err := client.Send("name"); err == nil && name != "" {
+			client.name = name
+		}
+	}
+	return client.name
+}
+
+// Takes the komi of the new game, and clears the engine's board before
+// its first move
+func (client *GTPClient) NewGame(config GameConfig) {
+	client.Komi = config.Komi
+	client.board = Board{}
+}
+
+// Asks the engine for its move, first bringing its board up to date
+// Problems with the engine, such as a timeout or an illegal move, are
+// returned as errors
+// An engine still thinking when the context is done is killed
+func (client *GTPClient) GenMove(ctx context.Context, pos Position) (Intersection, error) {
+	if err := client.sync(&pos); err != nil {
+		return PASS, err
+	}
+	color := "w"
+	if pos.blacksTurn {
+		color = "b"
+	}
+	response, err := client.sendContext(ctx, "genmove "+color)
+	if err != nil {
+		return PASS, err
+	}
+	if strings.ToLower(response) == "resign" {
+		return RESIGN, nil
+	}
+	intn, err := parseGTPVertex(response, pos.board.size)
+	if err != nil {
+		return PASS, err
+	}
+	if !pos.isLegal(intn) {
+		// Our board no longer matches the engine's, so set it up
+		// again on the next move
+		client.board = Board{}
+		return PASS, fmt.Errorf("GTP engine played illegal move %s", response)
+	}
+	if intn != PASS {
+		if pos.blacksTurn {
+			client.board.playBlackStone(intn)
+		} else {
+			client.board.playWhiteStone(intn)
+		}
+	}
+	client.engineBlacksTurn = !pos.blacksTurn
+	return intn, nil
+}
+
+// Moves are sent to the engine when it is next asked for a move
+func (client *GTPClient) Observe(color Color, move Intersection) {}
+
+func (client *GTPClient) GameOver(result GameResult) {}
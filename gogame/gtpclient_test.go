@@ -0,0 +1,158 @@
+package gogame
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Runs as a scripted GTP engine when the test binary is started by one of
+// the tests below
+// GTP_STUB_GENMOVE is its reply to genmove: a vertex, "resign", "?" and an
+// error message, "hang" to never reply, or "exit" to quit at once
+// Every command is written to the file GTP_STUB_LOG
+func TestGTPStubEngine(t *testing.T) {
+	if os.Getenv("GTP_STUB") == "" {
+		t.Skip("only run as a stub engine")
+	}
+	log, err := os.Create(os.Getenv("GTP_STUB_LOG"))
+	if err != nil {
+		os.Exit(1)
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		command := scanner.Text()
+		fmt.Fprintln(log, command)
+		fields := strings.Fields(command)
+		switch fields[0] {
+		case "protocol_version":
+			fmt.Print("= 2\n\n")
+		case "name":
+			fmt.Print("= stub\n\n")
+		case "genmove":
+			reply := os.Getenv("GTP_STUB_GENMOVE")
+			switch {
+			case reply == "hang":
+				time.Sleep(time.Hour)
+			case reply == "exit":
+				log.Close()
+				os.Exit(0)
+			case strings.HasPrefix(reply, "?"):
+				fmt.Printf("? %s\n\n", reply[1:])
+			default:
+				fmt.Printf("= %s\n\n", reply)
+			}
+		case "quit":
+			fmt.Print("=\n\n")
+			log.Close()
+			os.Exit(0)
+		default:
+			fmt.Print("=\n\n")
+		}
+	}
+	log.Close()
+	os.Exit(0)
+}
+
+// Starts the stub engine with the given reply to genmove, and returns it
+// with the file it logs commands to
+func startStubEngine(t *testing.T, genmove string, timeout time.Duration) (*GTPClient, string) {
+	log := filepath.Join(t.TempDir(), "commands")
+	t.Setenv("GTP_STUB", "1")
+	t.Setenv("GTP_STUB_GENMOVE", genmove)
+	t.Setenv("GTP_STUB_LOG", log)
+	client, err := NewGTPClient(timeout, os.Args[0], "-test.run=^TestGTPStubEngine$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, log
+}
+
+// A position with white to move after three stones
+func stubPosition() Position {
+	return positionFromRows([]string{
+		".........",
+		".........",
+		"..X......",
+		".........",
+		"....O....",
+		".........",
+		"......X..",
+		".........",
+		".........",
+	}, false, ChineseRules)
+}
+
+// Asks the stub engine for a move in a new game with komi 6.5
+func stubMove(t *testing.T, genmove string, timeout time.Duration) (Intersection, error, string) {
+	client, log := startStubEngine(t, genmove, timeout)
+	client.NewGame(GameConfig{Size: 9, Rules: ChineseRules, Komi: 6.5})
+	move, err := client.GenMove(context.Background(), stubPosition())
+	client.Close()
+	commands, readErr := os.ReadFile(log)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	return move, err, string(commands)
+}
+
+func TestGTPClientSyncsBoardAndKomi(t *testing.T) {
+	move, err, commands := stubMove(t, "C3", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if move != Point(6, 2) {
+		t.Errorf("got move %s, want 6 2", move)
+	}
+	for _, want := range []string{"boardsize 9", "clear_board", "komi 6.5", "play b C7", "play w E5", "play b G3", "genmove w"} {
+		if !strings.Contains(commands, want+"\n") {
+			t.Errorf("engine was not sent %q, only:\n%s", want, commands)
+		}
+	}
+}
+
+func TestGTPClientResigns(t *testing.T) {
+	move, err, _ := stubMove(t, "resign", 0)
+	if err != nil || move != RESIGN {
+		t.Errorf("got %s and %v, want a resignation", move, err)
+	}
+}
+
+func TestGTPClientRejectsBadMoves(t *testing.T) {
+	for _, reply := range []string{"C7", "Z99", "nonsense"} {
+		if _, err, _ := stubMove(t, reply, 0); err == nil {
+			t.Errorf("engine reply %q was accepted", reply)
+		}
+	}
+}
+
+func TestGTPClientReportsEngineErrors(t *testing.T) {
+	_, err, _ := stubMove(t, "?cannot think", 0)
+	if err == nil || !strings.Contains(err.Error(), "cannot think") {
+		t.Errorf("got error %v, want the engine's", err)
+	}
+}
+
+func TestGTPClientTimesOut(t *testing.T) {
+	client, _ := startStubEngine(t, "hang", 100*time.Millisecond)
+	defer client.Close()
+	client.NewGame(GameConfig{Size: 9, Rules: ChineseRules, Komi: 6.5})
+	if _, err := client.GenMove(context.Background(), stubPosition()); err == nil {
+		t.Fatal("engine that never replied gave a move")
+	}
+	if _, err := client.Send("name"); err == nil {
+		t.Error("engine still used after timing out")
+	}
+}
+
+func TestGTPClientNoticesExit(t *testing.T) {
+	_, err, _ := stubMove(t, "exit", 10*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "exited") {
+		t.Errorf("got error %v, want the engine to have exited", err)
+	}
+}
@@ -0,0 +1,110 @@
+package gogame
+
+import (
+	"context"
+)
+
+// Option to give white the given komi, in place of the standard komi
+// of the rules
+func Komi(komi float64) GameOption {
+	return func(config *GameConfig) {
+		config.Komi = komi
+		config.komiSet = true
+	}
+}
+
+// Option to give black a handicap of n stones on the standard points
+// With a handicap of 2 or more, white moves first
+func FixedHandicap(n int) GameOption {
+	return func(config *GameConfig) {
+		config.Handicap = n
+		config.FreeHandicap = false
+	}
+}
+
+// Option to give black a handicap of n stones, which the black player
+// places where it likes before the game starts
+func FreeHandicap(n int) GameOption {
+	return func(config *GameConfig) {
+		config.Handicap = n
+		config.FreeHandicap = true
+	}
+}
+
+// Returns the most fixed handicap stones a board of the given size takes
+// Small boards take none, and boards without a center point take four
+func maxFixedHandicap(size uint8) int {
+	if size < 7 {
+		return 0
+	}
+	if size%2 == 0 || size == 7 {
+		return 4
+	}
+	return 9
+}
+
+// Returns the standard points for n handicap stones, in the order of
+// the GTP fixed_handicap command
+// Panics if the board cannot take n fixed handicap stones
+func fixedHandicapPoints(size uint8, n int) []Intersection {
+	if n < 2 || n > maxFixedHandicap(size) {
+		panic("Fixed handicap out of range for board size")
+	}
+	// Star points are on the third line, or the fourth for large boards
+	var low uint8 = 2
+	if size >= 13 {
+		low = 3
+	}
+	high := size - 1 - low
+	mid := size / 2
+	corners := []Intersection{{high, low}, {low, high}, {low, low}, {high, high}}
+	sides := []Intersection{{mid, low}, {mid, high}, {high, mid}, {low, mid}}
+	center := Intersection{mid, mid}
+
+	points := make([]Intersection, 0, n)
+	if n <= 4 {
+		return append(points, corners[:n]...)
+	}
+	points = append(points, corners...)
+	if n%2 == 1 {
+		// Odd handicaps take the center, the rest go on the sides
+		points = append(points, sides[:n-5]...)
+		return append(points, center)
+	}
+	return append(points, sides[:n-4]...)
+}
+
+// Places the handicap stones on the first board of the game
+// The black player chooses free handicap stones, and stops placing
+// early if it passes, resigns, fails or makes an illegal choice
+func (game *Game) placeHandicap() {
+	n := game.Config.Handicap
+	if n < 2 {
+		return
+	}
+	board := &game.BoardList[0]
+	if !game.Config.FreeHandicap {
+		for _, intn := range fixedHandicapPoints(board.size, n) {
+			board.placeBlackStone(intn)
+		}
+		return
+	}
+	if n > int(board.size)*int(board.size)-1 {
+		panic("Free handicap out of range for board size")
+	}
+	for placed := 0; placed < n; placed++ {
+		// Only black stones are on the board, so only occupied points
+		// are illegal
+		var pos Position
+		pos.board = *board
+		pos.blacksTurn = true
+		for i := uint8(0); i < board.size; i++ {
+			pos.illegal[i] = board.black[i]
+		}
+		intn, err := safeGenMove(context.Background(), game.BlackPlayer, pos)
+		if err != nil || intn == PASS || intn == RESIGN || !pos.isLegal(intn) {
+			return
+		}
+		board.placeBlackStone(intn)
+	}
+}
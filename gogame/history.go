@@ -0,0 +1,155 @@
+package gogame
+
+import (
+	"fmt"
+	"time"
+)
+
+// A human player returns UNDO to take back their last move and the
+// reply to it, and move again
+var UNDO Intersection = Intersection{MAX_SIZE, MAX_SIZE + 2}
+
+// A move of the game, and what it did
+type Move struct {
+	Color Color
+	// The point played, or PASS
+	Point Intersection
+	// Opponent stones captured, and the player's own stones lost to suicide
+	Captures int
+	Suicides int
+	// Time the player took, zero for moves not played through PlayGame
+	Time time.Duration
+}
+
+// Shows the color and point of the move, such as "B 3 3" or "W pass"
+func (move Move) String() string {
+	return fmt.Sprintf("%s %s", move.Color, move.Point)
+}
+
+// Plays a stone of the color on the board, or passes
+// Only checks that the intersection is empty
+// Returns the move, with the stones it took
+func playMove(board *Board, color Color, intn Intersection) (Move, error) {
+	move := Move{Color: color, Point: intn}
+	if intn == PASS {
+		return move, nil
+	}
+	if !board.isEmpty(intn) {
+		return move, fmt.Errorf("move %s at occupied point", sgfPoint(intn))
+	}
+	blackBefore, whiteBefore := board.countStones()
+	if color == Black {
+		board.playBlackStone(intn)
+	} else {
+		board.playWhiteStone(intn)
+	}
+	blackAfter, whiteAfter := board.countStones()
+	if color == Black {
+		move.Captures = whiteBefore - whiteAfter
+		move.Suicides = blackBefore + 1 - blackAfter
+	} else {
+		move.Captures = blackBefore - blackAfter
+		move.Suicides = whiteBefore + 1 - whiteAfter
+	}
+	return move, nil
+}
+
+// Plays a move for the player to move, and adds it and its board to the
+// game, without touching the moves that were taken back
+func (game *Game) addMove(intn Intersection) error {
+	board := game.BoardList[len(game.BoardList)-1]
+	blacksTurn := game.blacksTurnAt(len(game.BoardList) - 1)
+	move, err := playMove(&board, colorOf(blacksTurn), intn)
+	if err != nil {
+		return err
+	}
+	game.BoardList = append(game.BoardList, board)
+	game.Moves = append(game.Moves, move)
+	game.recordPosition(&board, !blacksTurn)
+	return nil
+}
+
+// Takes back the last move of the game, and returns it
+// The clocks go back to where they were before the move
+// Returns false if there are no moves to take back
+func (game *Game) takeBack() (Move, bool) {
+	last := len(game.Moves) - 1
+	if last < 0 {
+		return Move{}, false
+	}
+	game.forgetPosition(&game.BoardList[last+1], game.blacksTurnAt(last+1))
+	move := game.Moves[last]
+	game.BoardList = game.BoardList[:last+1]
+	game.Moves = game.Moves[:last]
+	game.resetClocks()
+	return move, true
+}
+
+// Sets each player's clock from the time taken by the moves played
+func (game *Game) resetClocks() {
+	game.BlackClock = newClock(game.Config.Time)
+	game.WhiteClock = newClock(game.Config.Time)
+	for _, move := range game.Moves {
+		if move.Color == Black {
+			game.BlackClock.spend(move.Time)
+		} else {
+			game.WhiteClock.spend(move.Time)
+		}
+	}
+}
+
+// Sits the player down to the game again, and shows it each move played,
+// as after moves are taken back
+func (game *Game) replayTo(player Player) {
+	player.NewGame(game.Config)
+	for _, move := range game.Moves {
+		player.Observe(move.Color, move.Point)
+	}
+}
+
+// Takes back the last move of the game, which Redo can play again
+// The result of a finished game is kept until a new move is played
+// The players are not told, but the clocks go back
+// Returns false if there are no moves to take back
+func (game *Game) Undo() bool {
+	move, ok := game.takeBack()
+	if ok {
+		game.undone = append(game.undone, move)
+	}
+	return ok
+}
+
+// Plays again the last move taken back by Undo, taking its time off the
+// clock again
+// Returns false if there is none, or a new move has been played since
+func (game *Game) Redo() bool {
+	last := len(game.undone) - 1
+	if last < 0 {
+		return false
+	}
+	move := game.undone[last]
+	if err := game.addMove(move.Point); err != nil {
+		return false
+	}
+	game.Moves[len(game.Moves)-1].Time = move.Time
+	game.undone = game.undone[:last]
+	game.resetClocks()
+	return true
+}
+
+// Undoes or redoes moves until n moves of the game have been played
+func (game *Game) GoTo(n int) error {
+	total := len(game.Moves) + len(game.undone)
+	if n < 0 || n > total {
+		return fmt.Errorf("cannot go to move %d of a game with %d moves", n, total)
+	}
+	for len(game.Moves) > n {
+		game.Undo()
+	}
+	for len(game.Moves) < n {
+		if !game.Redo() {
+			return fmt.Errorf("cannot redo move %d", len(game.Moves)+1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package gogame
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// A player that plays its moves in turn and then passes, and keeps the
+// moves it has been shown since it last sat down to the game
+type scriptedPlayer struct {
+	moves    []Intersection
+	observed []Move
+}
+
+func (player *scriptedPlayer) Name() string {
+	return "scripted"
+}
+
+func (player *scriptedPlayer) NewGame(config GameConfig) {
+	player.observed = nil
+}
+
+func (player *scriptedPlayer) GenMove(ctx context.Context, pos Position) (Intersection, error) {
+	if len(player.moves) == 0 {
+		return PASS, nil
+	}
+	move := player.moves[0]
+	player.moves = player.moves[1:]
+	return move, nil
+}
+
+func (player *scriptedPlayer) Observe(color Color, move Intersection) {
+	player.observed = append(player.observed, Move{Color: color, Point: move})
+}
+
+func (player *scriptedPlayer) GameOver(result GameResult) {}
+
+// The colors and points of the moves, without what they did
+func movesPlayed(moves []Move) []Move {
+	played := []Move{}
+	for _, move := range moves {
+		played = append(played, Move{Color: move.Color, Point: move.Point})
+	}
+	return played
+}
+
+// A game of four moves on a 9x9 board with a minute each, where the kth
+// move took k seconds
+func timedGame(t *testing.T) Game {
+	passer := FuncPlayer("pass", func(pos Position) Intersection { return PASS })
+	game := MakeGame(passer, passer, BoardSize(9), AbsoluteClock(time.Minute))
+	for k, intn := range []Intersection{{2, 2}, {6, 6}, {2, 6}, {6, 2}} {
+		if err := game.addMove(intn); err != nil {
+			t.Fatal(err)
+		}
+		game.Moves[k].Time = time.Duration(k+1) * time.Second
+	}
+	game.resetClocks()
+	return game
+}
+
+func TestUndoRedo(t *testing.T) {
+	game := timedGame(t)
+	boards := append([]Board{}, game.BoardList...)
+	moves := append([]Move{}, game.Moves...)
+	if !game.Undo() || !game.Undo() {
+		t.Fatal("could not undo two moves")
+	}
+	if len(game.Moves) != 2 || game.BoardList[2] != boards[2] || len(game.BoardList) != 3 {
+		t.Fatalf("undo left %d moves, want the first 2", len(game.Moves))
+	}
+	if game.BlackClock.Remaining != 59*time.Second || game.WhiteClock.Remaining != 58*time.Second {
+		t.Errorf("clocks %s and %s after undo, want 59s and 58s", game.BlackClock, game.WhiteClock)
+	}
+	if !game.Redo() || !game.Redo() || game.Redo() {
+		t.Fatal("could not redo exactly the two moves")
+	}
+	if !reflect.DeepEqual(game.Moves, moves) || !reflect.DeepEqual(game.BoardList, boards) {
+		t.Errorf("redo gave moves %v, want %v", game.Moves, moves)
+	}
+	if game.BlackClock.Remaining != 56*time.Second || game.WhiteClock.Remaining != 54*time.Second {
+		t.Errorf("clocks %s and %s after redo, want 56s and 54s", game.BlackClock, game.WhiteClock)
+	}
+}
+
+func TestRedoAfterNewMove(t *testing.T) {
+	game := timedGame(t)
+	game.Undo()
+	if err := game.appendMove(Intersection{4, 4}); err != nil {
+		t.Fatal(err)
+	}
+	if game.Redo() {
+		t.Error("redid a move after a new move was played")
+	}
+}
+
+func TestUndoEmptyGame(t *testing.T) {
+	passer := FuncPlayer("pass", func(pos Position) Intersection { return PASS })
+	game := MakeGame(passer, passer, BoardSize(9))
+	if game.Undo() {
+		t.Error("undid a move of a game with none")
+	}
+}
+
+func TestGoTo(t *testing.T) {
+	game := timedGame(t)
+	boards := append([]Board{}, game.BoardList...)
+	for _, n := range []int{1, 0, 3, 4, 2} {
+		if err := game.GoTo(n); err != nil {
+			t.Fatal(err)
+		}
+		if len(game.Moves) != n || !reflect.DeepEqual(game.BoardList, boards[:n+1]) {
+			t.Errorf("went to %d moves, got %d", n, len(game.Moves))
+		}
+		want := game
+		want.resetClocks()
+		if game.BlackClock != want.BlackClock || game.WhiteClock != want.WhiteClock {
+			t.Errorf("at move %d clocks %s and %s, want %s and %s", n, game.BlackClock, game.WhiteClock, want.BlackClock, want.WhiteClock)
+		}
+	}
+	for _, n := range []int{-1, 5} {
+		if err := game.GoTo(n); err == nil {
+			t.Errorf("went to move %d of 4", n)
+		}
+	}
+}
+
+func TestPlayGameUndo(t *testing.T) {
+	black := &scriptedPlayer{moves: []Intersection{{2, 2}, UNDO, {4, 4}}}
+	white := &scriptedPlayer{moves: []Intersection{{6, 6}}}
+	game := MakeGame(black, white, BoardSize(9), AbsoluteClock(time.Minute))
+	game.PlayGame()
+	want := []Move{
+		{Color: Black, Point: Intersection{4, 4}},
+		{Color: White, Point: PASS},
+		{Color: Black, Point: PASS},
+	}
+	if got := movesPlayed(game.Moves); !reflect.DeepEqual(got, want) {
+		t.Fatalf("game moves %v, want %v", got, want)
+	}
+	for _, player := range []*scriptedPlayer{black, white} {
+		if !reflect.DeepEqual(player.observed, want) {
+			t.Errorf("player was shown %v, want %v", player.observed, want)
+		}
+	}
+	clocks := game
+	clocks.resetClocks()
+	if game.BlackClock != clocks.BlackClock || game.WhiteClock != clocks.WhiteClock {
+		t.Errorf("clocks %s and %s, want %s and %s from the moves kept", game.BlackClock, game.WhiteClock, clocks.BlackClock, clocks.WhiteClock)
+	}
+}
@@ -0,0 +1,480 @@
+package gogame
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Settings for the Monte Carlo tree search player
+type MCTSOptions struct {
+	// Number of playouts per move, zero for no limit
+	// With no limit on playouts or time, 1000 playouts are made
+	Playouts int
+	// Time to think per move, zero for no limit
+	TimeBudget time.Duration
+	// Weight of the exploration term in the UCT formula
+	Exploration float64
+	// Rules and komi used to score playouts
+	// The player takes them from the game it sits down to
+	Rules Ruleset
+	Komi  float64
+	// Seed for the random playouts, zero to seed from the clock
+	// With a seed and no time budget, moves are the same from run to run
+	Seed int64
+	// Number of independent trees searched at once, whose visit counts
+	// are added up at the root. Zero uses every CPU
+	Workers int
+	// Equivalence parameter of the RAVE schedule, the number of visits
+	// at which a move's own results and its AMAF results are given equal
+	// weight. Zero turns RAVE off
+	RAVEEquivalence float64
+	// Resign when the best move wins fewer playouts than this fraction
+	// Zero never resigns
+	ResignThreshold float64
+}
+
+// Returns the standard settings: 1000 playouts per move with RAVE,
+// Chinese rules with their komi, resigning below a 5% win rate
+func DefaultMCTSOptions() MCTSOptions {
+	return MCTSOptions{
+		Playouts:        1000,
+		Exploration:     0.4,
+		Rules:           ChineseRules,
+		Komi:            ChineseRules.Komi,
+		RAVEEquivalence: 1000,
+		ResignThreshold: 0.05,
+	}
+}
+
+// Fewest visits the best move needs before its win rate is trusted
+// enough to resign on
+const MCTS_RESIGN_VISITS = 100
+
+// A node of the search tree, for the position after a move
+type mctsNode struct {
+	// The move leading to the node, and whether black made it
+	move       Intersection
+	blackMoved bool
+	parent     *mctsNode
+	children   []*mctsNode
+	// The position after the move, made when the node is first visited
+	// Its illegal moves are marked when the node is expanded
+	pos      *Position
+	expanded bool
+	// Number of passes in a row ending with the move
+	passes int
+	// Prisoners taken by black minus those taken by white, since the root
+	prisoners int
+	// Playouts through the node, and how many the mover won
+	visits int
+	wins   float64
+	// Playouts where the mover made the move at any later time (AMAF),
+	// and how many of those the mover won
+	raveVisits int
+	raveWins   float64
+}
+
+// A set of intersections, as row bitmaps
+type moveSet [MAX_SIZE]uint32
+
+func (set *moveSet) add(intn Intersection) {
+	set[intn.x] |= 1 << intn.y
+}
+
+func (set *moveSet) has(intn Intersection) bool {
+	return set[intn.x]&(1<<intn.y) != 0
+}
+
+// A search from one position
+type mctsSearch struct {
+	options MCTSOptions
+	random  *rand.Rand
+	root    *mctsNode
+}
+
+// A player that searches with UCT and random playouts
+type mctsPlayer struct {
+	// The options, with the rules and komi of the game, and the source
+	// that seeds each move's searches
+	// Both are guarded, as a search that ran out of time may still be
+	// starting when the next move or game begins
+	options MCTSOptions
+	random  *rand.Rand
+	mutex   sync.Mutex
+}
+
+// Makes a player that searches with UCT and random playouts
+// Playouts never fill the player's own eyes
+// The playouts are shared among the workers, each searching its own tree
+// Playouts are scored under the rules and komi of the game being played
+func MCTSPlayer(options MCTSOptions) Player {
+	seed := options.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &mctsPlayer{options: options, random: rand.New(rand.NewSource(seed))}
+}
+
+func (player *mctsPlayer) Name() string {
+	return "mcts"
+}
+
+func (player *mctsPlayer) NewGame(config GameConfig) {
+	player.mutex.Lock()
+	defer player.mutex.Unlock()
+	player.options.Rules = config.Rules
+	player.options.Komi = config.Komi
+}
+
+// Searches until the playouts or time budget are spent, or the context
+// is done
+func (player *mctsPlayer) GenMove(ctx context.Context, pos Position) (Intersection, error) {
+	player.mutex.Lock()
+	options := player.options
+	random := rand.New(rand.NewSource(player.random.Int63()))
+	player.mutex.Unlock()
+	return bestMove(runSearches(ctx, options, random, pos), options.ResignThreshold), nil
+}
+
+func (player *mctsPlayer) Observe(color Color, move Intersection) {}
+
+func (player *mctsPlayer) GameOver(result GameResult) {}
+
+// Searches the position with each worker, and returns their searches
+// Each worker's playouts are seeded from random, in order
+// The workers stop early once the context is done
+func runSearches(ctx context.Context, options MCTSOptions, random *rand.Rand, pos Position) []*mctsSearch {
+	playouts := options.Playouts
+	if playouts == 0 && options.TimeBudget == 0 {
+		playouts = 1000
+	}
+	workers := options.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if playouts > 0 && workers > playouts {
+		workers = playouts
+	}
+	searches := make([]*mctsSearch, workers)
+	var wait sync.WaitGroup
+	for w := range searches {
+		share := playouts / workers
+		if w < playouts%workers {
+			share++
+		}
+		searches[w] = newMCTSSearch(options, rand.New(rand.NewSource(random.Int63())), pos)
+		wait.Add(1)
+		go func(search *mctsSearch) {
+			defer wait.Done()
+			search.run(ctx, share)
+		}(searches[w])
+	}
+	wait.Wait()
+	return searches
+}
+
+// Returns the move with the most visits at the roots of all the searches,
+// or a pass if nothing was tried
+// Ties go to the move first tried, so the choice is the same from run to run
+// Resigns if the move wins less than the resign threshold of its playouts
+func bestMove(searches []*mctsSearch, resignThreshold float64) Intersection {
+	visits := make(map[Intersection]int)
+	wins := make(map[Intersection]float64)
+	moves := []Intersection{}
+	for _, search := range searches {
+		for _, child := range search.root.children {
+			if _, ok := visits[child.move]; !ok {
+				moves = append(moves, child.move)
+			}
+			visits[child.move] += child.visits
+			wins[child.move] += child.wins
+		}
+	}
+	best := PASS
+	bestVisits := -1
+	for _, move := range moves {
+		if visits[move] > bestVisits {
+			best = move
+			bestVisits = visits[move]
+		}
+	}
+	if bestVisits >= MCTS_RESIGN_VISITS && wins[best] < resignThreshold*float64(bestVisits) {
+		return RESIGN
+	}
+	return best
+}
+
+// Sets up a search from the given position
+// The position's illegal moves, which include superko, are used at the root
+// A pass that ended the last move is counted, as are the prisoners taken
+// so far
+func newMCTSSearch(options MCTSOptions, random *rand.Rand, pos Position) *mctsSearch {
+	search := &mctsSearch{options: options, random: random}
+	search.root = &mctsNode{pos: &pos, blackMoved: !pos.blacksTurn}
+	if pos.lastMovePassed() {
+		search.root.passes = 1
+	}
+	search.root.prisoners = pos.captures[0] - pos.captures[1]
+	search.addChildren(search.root)
+	return search
+}
+
+// Returns the moves worth searching from a position: legal moves that
+// are not worse than passing, nor inside a pass-alive area, and passing
+func candidateMoves(pos *Position) []Intersection {
+	moves := []Intersection{PASS}
+	pointless := pos.pointlessMoves()
+	for i := uint8(0); i < pos.board.size; i++ {
+		for j := uint8(0); j < pos.board.size; j++ {
+			intn := Intersection{i, j}
+			if !pointless.has(intn) {
+				moves = append(moves, intn)
+			}
+		}
+	}
+	return moves
+}
+
+// Runs the given number of playouts, or until the time budget is spent
+// or the context is done
+// Zero playouts means no limit
+func (search *mctsSearch) run(ctx context.Context, playouts int) {
+	start := time.Now()
+	for n := 0; playouts == 0 || n < playouts; n++ {
+		if search.options.TimeBudget > 0 && time.Since(start) >= search.options.TimeBudget {
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		search.iterate()
+	}
+}
+
+// One iteration: walk down the tree to a new node, expanding nodes
+// visited before, then play out and back up the result
+func (search *mctsSearch) iterate() {
+	node := search.root
+	for node.passes < 2 {
+		if !node.expanded {
+			search.expand(node)
+		}
+		node = search.selectChild(node)
+		if node.pos == nil {
+			search.makePosition(node)
+			break
+		}
+	}
+	// Playout, recording the moves of each color for AMAF
+	var played [2]moveSet
+	blackMargin := search.playout(node, &played)
+	// Backup
+	rave := search.options.RAVEEquivalence > 0
+	for ; node != nil; node = node.parent {
+		node.visits++
+		node.wins += playoutWin(blackMargin, node.blackMoved)
+		if rave {
+			for _, child := range node.children {
+				if child.move != PASS && played[colorIndex(child.blackMoved)].has(child.move) {
+					child.raveVisits++
+					child.raveWins += playoutWin(blackMargin, child.blackMoved)
+				}
+			}
+		}
+		if node.move != PASS && node.parent != nil {
+			played[colorIndex(node.blackMoved)].add(node.move)
+		}
+	}
+}
+
+// Returns 1 if the player won a playout with the given margin for black,
+// 0 if they lost and 0.5 for a draw
+func playoutWin(blackMargin float64, black bool) float64 {
+	if blackMargin == 0 {
+		return 0.5
+	}
+	if (blackMargin > 0) == black {
+		return 1
+	}
+	return 0
+}
+
+// Index for per-color arrays, 0 for black and 1 for white
+func colorIndex(black bool) int {
+	if black {
+		return 0
+	}
+	return 1
+}
+
+// Returns the child with the highest value
+// Without RAVE this is the UCT value, and unvisited children come first
+// With RAVE the mean result is blended with the AMAF mean, the AMAF
+// weight falling as sqrt(k / (3n + k)) with n visits
+// Children were shuffled when made, so ties fall to a random child
+func (search *mctsSearch) selectChild(node *mctsNode) *mctsNode {
+	logVisits := math.Log(float64(node.visits + 1))
+	equivalence := search.options.RAVEEquivalence
+	var best *mctsNode
+	bestValue := math.Inf(-1)
+	for _, child := range node.children {
+		var value float64
+		if child.visits == 0 && (equivalence <= 0 || child.raveVisits == 0) {
+			value = math.Inf(1)
+		} else if child.visits == 0 {
+			value = child.raveWins / float64(child.raveVisits)
+		} else {
+			value = child.wins / float64(child.visits)
+			if equivalence > 0 && child.raveVisits > 0 {
+				beta := math.Sqrt(equivalence / (3*float64(child.visits) + equivalence))
+				value = (1-beta)*value + beta*child.raveWins/float64(child.raveVisits)
+			}
+			value += search.options.Exploration * math.Sqrt(logVisits/float64(child.visits))
+		}
+		if value > bestValue {
+			best = child
+			bestValue = value
+		}
+	}
+	return best
+}
+
+// Marks the illegal moves of a node, and makes its children
+// Inside the tree only simple ko is checked
+func (search *mctsSearch) expand(node *mctsNode) {
+	previous := &node.parent.pos.board
+	node.pos.markIllegal(search.options.Rules.Suicide, func(board *Board) bool {
+		return *board == *previous
+	})
+	search.addChildren(node)
+}
+
+// Makes a child for each candidate move of a node, in random order
+func (search *mctsSearch) addChildren(node *mctsNode) {
+	moves := candidateMoves(node.pos)
+	search.random.Shuffle(len(moves), func(a, b int) {
+		moves[a], moves[b] = moves[b], moves[a]
+	})
+	node.children = make([]*mctsNode, len(moves))
+	for k, move := range moves {
+		node.children[k] = &mctsNode{move: move, blackMoved: node.pos.blacksTurn, parent: node}
+	}
+	node.expanded = true
+}
+
+// Makes the position of a node, by playing its move on its parent's
+func (search *mctsSearch) makePosition(node *mctsNode) {
+	parent := node.parent
+	node.pos = &Position{board: parent.pos.board, blacksTurn: !parent.pos.blacksTurn}
+	node.prisoners = parent.prisoners
+	if node.move == PASS {
+		node.passes = parent.passes + 1
+	} else {
+		node.prisoners += playCounting(&node.pos.board, node.blackMoved, node.move)
+	}
+}
+
+// Plays a stone, and returns the prisoners black took minus those white took
+// Stones removed by suicide are taken by the opponent
+func playCounting(board *Board, black bool, intn Intersection) int {
+	blackBefore, whiteBefore := board.countStones()
+	if black {
+		board.playBlackStone(intn)
+	} else {
+		board.playWhiteStone(intn)
+	}
+	blackAfter, whiteAfter := board.countStones()
+	if black {
+		return (whiteBefore - whiteAfter) - (blackBefore + 1 - blackAfter)
+	}
+	return (whiteBefore + 1 - whiteAfter) - (blackBefore - blackAfter)
+}
+
+// Plays random moves from the node until both players pass
+// The moves of each color are added to played
+// Returns black's winning margin, with komi
+func (search *mctsSearch) playout(node *mctsNode, played *[2]moveSet) float64 {
+	// Play the node's move on its parent's board, so a ko it took is known
+	var cb chainBoard
+	if node.parent != nil && node.move != PASS {
+		cb = makeChainBoard(&node.parent.pos.board)
+		cb.play(cb.cell(node.move), colorOf(node.blackMoved))
+	} else {
+		cb = makeChainBoard(&node.pos.board)
+	}
+	blacksTurn := node.pos.blacksTurn
+	suicide := search.options.Rules.Suicide
+	prisoners := node.prisoners
+	passes := node.passes
+	size := int(cb.size)
+	areaScoring := search.options.Rules.Scoring != TerritoryScoring
+	empty := make([]int, 0, size*size)
+	for moves := 0; passes < 2 && moves < 3*size*size; moves++ {
+		// Once there have been as many moves as points, look now and then
+		// for pass-alive groups that already decide the game
+		if areaScoring && moves >= size*size && moves%(2*size) == 0 {
+			board := cb.board()
+			if margin, settled := settledMargin(&board, search.options.Komi); settled {
+				return margin
+			}
+		}
+		// List the empty points, and try them in random order
+		empty = empty[:0]
+		for i := uint8(0); i < cb.size; i++ {
+			for j := uint8(0); j < cb.size; j++ {
+				cell := cb.cell(Intersection{i, j})
+				if cb.color[cell] == Empty {
+					empty = append(empty, cell)
+				}
+			}
+		}
+		color := colorOf(blacksTurn)
+		moved := false
+		for len(empty) > 0 {
+			k := search.random.Intn(len(empty))
+			cell := empty[k]
+			empty[k] = empty[len(empty)-1]
+			empty = empty[:len(empty)-1]
+			if cb.isEye(cell, color) || !cb.isLegal(cell, color, suicide) {
+				continue
+			}
+			captured, lost := cb.play(cell, color)
+			if blacksTurn {
+				prisoners += captured - lost
+			} else {
+				prisoners += lost - captured
+			}
+			played[colorIndex(blacksTurn)].add(cb.intersection(cell))
+			moved = true
+			break
+		}
+		if moved {
+			passes = 0
+		} else {
+			cb.pass()
+			passes++
+		}
+		blacksTurn = !blacksTurn
+	}
+	return scoreMargin(&cb, search.options.Rules, search.options.Komi, prisoners)
+}
+
+// Returns black's winning margin on a finished board under the rules
+// Dead stones in areas secured by pass-alive chains are taken off, as in
+// the scoring of a game
+// prisoners is the prisoners black took minus those white took
+func scoreMargin(cb *chainBoard, rules Ruleset, komi float64, prisoners int) float64 {
+	board := cb.board()
+	var blackScore, whiteScore int
+	if rules.Scoring == TerritoryScoring {
+		blackScore, whiteScore = board.territoryScoring()
+		blackScore += prisoners
+	} else {
+		blackScore, whiteScore = board.chineseScoring()
+	}
+	return float64(blackScore-whiteScore) - komi
+}
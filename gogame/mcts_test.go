@@ -0,0 +1,123 @@
+package gogame
+
+import (
+	"context"
+	"math/rand"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Makes a position from rows of X for black, O for white and . for empty
+func positionFromRows(rows []string, blacksTurn bool, rules Ruleset) Position {
+	board := newBoard(uint8(len(rows)))
+	for i, row := range rows {
+		for j, c := range row {
+			switch c {
+			case 'X':
+				board.placeBlackStone(Intersection{uint8(i), uint8(j)})
+			case 'O':
+				board.placeWhiteStone(Intersection{uint8(i), uint8(j)})
+			}
+		}
+	}
+	pos := Position{board: board, blacksTurn: blacksTurn, rules: rules}
+	pos.history = []uint64{positionKey(&board, blacksTurn, rules.Ko)}
+	pos.markIllegal(rules.Suicide, pos.breaksKo)
+	return pos
+}
+
+// Black's comb on the left is pass-alive and holds a dead white stone in
+// each of its teeth, so black wins by 1.5 once they are taken off
+var deadStonesRows = []string{
+	"XO..XO...",
+	"XXXXXOOOO",
+	"XO..XO...",
+	"XXXXXOOOO",
+	"XO..XO...",
+	"XXXXXOOOO",
+	"XO..XO...",
+	"XXXXXOOOO",
+	"XO..XO...",
+}
+
+func TestScoringTakesOffDeadStones(t *testing.T) {
+	pos := positionFromRows(deadStonesRows, true, ChineseRules)
+	black, white := pos.board.chineseScoring()
+	if black != 45 || white != 36 {
+		t.Errorf("area scores %d and %d, want 45 and 36", black, white)
+	}
+	cb := makeChainBoard(&pos.board)
+	if margin := scoreMargin(&cb, ChineseRules, 7.5, 0); margin != 1.5 {
+		t.Errorf("playout margin %v, want 1.5", margin)
+	}
+}
+
+// Asks the MCTS player for a move in a game with the given komi
+func mctsMove(t *testing.T, pos Position, komi float64) Intersection {
+	options := DefaultMCTSOptions()
+	options.Seed = 1
+	options.Workers = 1
+	player := MCTSPlayer(options)
+	player.NewGame(GameConfig{Size: pos.board.size, Rules: pos.rules, Komi: komi})
+	move, err := player.GenMove(context.Background(), pos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return move
+}
+
+func TestMCTSDoesNotResignWonPosition(t *testing.T) {
+	pos := positionFromRows(deadStonesRows, true, ChineseRules)
+	if move := mctsMove(t, pos, 7.5); move == RESIGN {
+		t.Errorf("resigned a won position")
+	}
+}
+
+func TestMCTSTakesKomiFromGame(t *testing.T) {
+	pos := positionFromRows(deadStonesRows, true, ChineseRules)
+	if move := mctsMove(t, pos, 12.5); move != RESIGN {
+		t.Errorf("played %s in a position lost on komi, want resign", move)
+	}
+}
+
+func TestMCTSCountsOpponentPass(t *testing.T) {
+	pos := positionFromRows(deadStonesRows, false, ChineseRules)
+	search := newMCTSSearch(DefaultMCTSOptions(), rand.New(rand.NewSource(1)), pos)
+	if search.root.passes != 0 {
+		t.Errorf("root has %d passes before any move", search.root.passes)
+	}
+	next, err := pos.Play(PASS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	search = newMCTSSearch(DefaultMCTSOptions(), rand.New(rand.NewSource(1)), next)
+	if search.root.passes != 1 {
+		t.Errorf("root has %d passes after a pass, want 1", search.root.passes)
+	}
+}
+
+func TestMCTSStopsWhenOutOfTime(t *testing.T) {
+	rows := strings.Split(strings.Repeat(".........\n", 9), "\n")[:9]
+	pos := positionFromRows(rows, true, ChineseRules)
+	options := DefaultMCTSOptions()
+	options.Playouts = 10000000
+	options.Seed = 1
+	player := MCTSPlayer(options)
+	running := runtime.NumGoroutine()
+	// The second game and move begin while the first search may still be
+	// winding down, which the race detector checks
+	for k := 0; k < 2; k++ {
+		player.NewGame(GameConfig{Size: 9, Rules: ChineseRules, Komi: 7.5})
+		if _, err := genMoveWithin(player, pos, 20*time.Millisecond); err != context.DeadlineExceeded {
+			t.Fatalf("move %d: got error %v, want a timeout", k, err)
+		}
+	}
+	for wait := 0; runtime.NumGoroutine() > running; wait++ {
+		if wait == 100 {
+			t.Fatalf("searches still running after their moves timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -1,33 +1,112 @@
 package gogame
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"math/rand"
+	"os"
+	"strings"
 )
 
+// A player of games, which may keep state from move to move
+// The position passed to GenMove is always the true one
+type Player interface {
+	// Name of the player, as written in game records
+	Name() string
+	// Called when the player sits down to a new game
+	// A player playing both sides is told twice
+	// When moves are taken back the player sits down to the game again,
+	// and is shown each move still played
+	NewGame(config GameConfig)
+	// Chooses a move for the player to move, which may be PASS or RESIGN
+	// An error forfeits the game
+	GenMove(ctx context.Context, pos Position) (Intersection, error)
+	// Called with every move played after any handicap, including the
+	// player's own
+	Observe(color Color, move Intersection)
+	// Called when the game has ended
+	GameOver(result GameResult)
+}
+
+// A player made from a function that chooses moves
+type funcPlayer struct {
+	name string
+	move func(Position) Intersection
+}
+
+// Makes a player from a function such as RandomPlayer, which has no
+// state and ignores the rest of the game
+func FuncPlayer(name string, move func(Position) Intersection) Player {
+	return &funcPlayer{name, move}
+}
+
+func (player *funcPlayer) Name() string {
+	return player.name
+}
+
+func (player *funcPlayer) NewGame(config GameConfig) {}
+
+func (player *funcPlayer) GenMove(ctx context.Context, pos Position) (Intersection, error) {
+	return player.move(pos), nil
+}
+
+func (player *funcPlayer) Observe(color Color, move Intersection) {}
+
+func (player *funcPlayer) GameOver(result GameResult) {}
+
+// Lines typed by the human player
+var humanInput = bufio.NewReader(os.Stdin)
+
 // A function that gets user input to return an intersection.
+// Asks again on bad input, out of range coordinates or illegal moves
+// Passes if the input has ended, resigns on "resign", and takes back
+// the last two moves on "undo"
 func HumanPlayer(pos Position) Intersection {
 
+	size := pos.board.size
 	pos.board.PrintOut()
-	// Get first coordinate
-	fmt.Println("Please enter coordinates, separated by space ")
-	var i uint8
-	var j uint8
-
-	_, err := fmt.Scanf("%d %d", &i, &j)
-	if err != nil {
-		panic("Problem reading input")
-	}
+	for {
+		// Get both coordinates from a line
+		fmt.Printf("Please enter coordinates, separated by space (%d %d to pass, resign or undo)\n", size, size)
+		line, err := humanInput.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Println("No more input, passing")
+			return PASS
+		}
+		switch strings.TrimSpace(line) {
+		case "resign":
+			return RESIGN
+		case "undo":
+			// The history holds the first board and one more for each move
+			if len(pos.history) < 3 {
+				fmt.Println("There are no moves to undo, try again")
+				continue
+			}
+			return UNDO
+		}
+		var i, j int
+		if _, err := fmt.Sscanf(line, "%d %d", &i, &j); err != nil {
+			fmt.Println("Could not read two numbers, try again")
+			continue
+		}
 
-	if i == SIZE && j == SIZE {
-		return PASS
-	}
-	// Throw errors when i, j is out of range
-	if i >= SIZE || j >= SIZE {
-		panic("Entered coordinates out of range")
+		// Entering the board size for both coordinates passes
+		if i == int(size) && j == int(size) {
+			return PASS
+		}
+		if i < 0 || j < 0 || i >= int(size) || j >= int(size) {
+			fmt.Println("Entered coordinates out of range, try again")
+			continue
+		}
+		// Create and return the intersection, if legal
+		intn := Intersection{uint8(i), uint8(j)}
+		if !pos.isLegal(intn) {
+			fmt.Println("That move is illegal, try again")
+			continue
+		}
+		return intn
 	}
-	// Create and return the intersection
-	return Intersection{i, j}
 }
 
 // A function that ramdomly selects an intersection
@@ -38,8 +117,8 @@ func RandomPlayer(pos Position) Intersection {
 		if 0 == rand.Intn(50) {
 			return PASS
 		}
-		i := rand.Intn(int(SIZE))
-		j := rand.Intn(int(SIZE))
+		i := rand.Intn(int(pos.board.size))
+		j := rand.Intn(int(pos.board.size))
 		chosenIntn = Intersection{uint8(i), uint8(j)}
 		if pos.isLegal(chosenIntn) {
 			return chosenIntn
@@ -50,13 +129,14 @@ func RandomPlayer(pos Position) Intersection {
 // A function that scans right-left top-bottom and selects first legal move
 func BadPlayer(pos Position) Intersection {
 
+	size := pos.board.size
 	// Will pass 1 out of 20 times
 	if 0 == rand.Intn(20) {
 		return PASS
 	}
 	// Loop through all intersections, find first empty one
-	for i := 0; uint8(i) < SIZE; i++ {
-		for j := 0; uint8(j) < SIZE; j++ {
+	for i := 0; uint8(i) < size; i++ {
+		for j := 0; uint8(j) < size; j++ {
 			var intn Intersection = Intersection{uint8(i), uint8(j)}
 			if pos.isLegal(intn) {
 				return intn
@@ -70,10 +150,11 @@ func BadPlayer(pos Position) Intersection {
 // If board is completely empty, will play in the middle
 func SurroundPlayer(pos Position) Intersection {
 
+	size := pos.board.size
 	completelyEmpty := true
 	// Loop through all intersections
-	for i := 0; uint8(i) < SIZE; i++ {
-		for j := 0; uint8(j) < SIZE; j++ {
+	for i := 0; uint8(i) < size; i++ {
+		for j := 0; uint8(j) < size; j++ {
 			var intn Intersection = Intersection{uint8(i), uint8(j)}
 			if !pos.board.isEmpty(intn) {
 				completelyEmpty = false
@@ -81,7 +162,7 @@ func SurroundPlayer(pos Position) Intersection {
 			// If empty, see if it can be filled
 			if pos.blacksTurn {
 				if pos.board.isWhiteStone(intn) {
-					for _, adjIntn := range intn.adjacents() {
+					for _, adjIntn := range intn.adjacents(pos.board.size) {
 						if pos.isLegal(adjIntn) {
 							return adjIntn
 						}
@@ -89,7 +170,7 @@ func SurroundPlayer(pos Position) Intersection {
 				}
 			} else {
 				if pos.board.isBlackStone(intn) {
-					for _, adjIntn := range intn.adjacents() {
+					for _, adjIntn := range intn.adjacents(pos.board.size) {
 						if pos.isLegal(adjIntn) {
 							return adjIntn
 						}
@@ -108,8 +189,9 @@ func SurroundPlayer(pos Position) Intersection {
 // otherwise plays as SurroundPlayer
 func CapturePlayer(pos Position) Intersection {
 	board := pos.board
-	for i := 0; uint8(i) < SIZE; i++ {
-		for j := 0; uint8(j) < SIZE; j++ {
+	size := board.size
+	for i := 0; uint8(i) < size; i++ {
+		for j := 0; uint8(j) < size; j++ {
 			var intn Intersection = Intersection{uint8(i), uint8(j)}
 			// See if a play here by self will capture
 			if board.isEmpty(intn) && pos.isCapture(intn) {
@@ -0,0 +1,205 @@
+package gogame
+
+import (
+	"errors"
+)
+
+// The exported view of a position, for players outside the package
+// Positions are never changed by these methods, Play makes a new one
+
+// Returns the intersection in row x from the top and column y from the
+// left, counting from 0
+// Coordinates off the board give a point that is never legal
+func Point(x, y int) Intersection {
+	if x < 0 || y < 0 || x >= int(MAX_SIZE) || y >= int(MAX_SIZE) {
+		return Intersection{MAX_SIZE, 0}
+	}
+	return Intersection{uint8(x), uint8(y)}
+}
+
+// Returns the row of the intersection, counting from the top
+func (intn Intersection) X() int {
+	return int(intn.x)
+}
+
+// Returns the column of the intersection, counting from the left
+func (intn Intersection) Y() int {
+	return int(intn.y)
+}
+
+// Returns the number of rows and columns of the board
+func (pos *Position) Size() int {
+	return int(pos.board.size)
+}
+
+// Returns the color of the stone in row x and column y, or Empty
+func (pos *Position) At(x, y int) Color {
+	return pos.board.colorAt(Point(x, y))
+}
+
+// Returns the color of the stone on an intersection, or Empty
+func (board *Board) colorAt(intn Intersection) Color {
+	if !board.onBoard(intn) {
+		return Empty
+	}
+	if board.isBlackStone(intn) {
+		return Black
+	}
+	if board.isWhiteStone(intn) {
+		return White
+	}
+	return Empty
+}
+
+// Returns the color of the player to move
+func (pos *Position) ToMove() Color {
+	return colorOf(pos.blacksTurn)
+}
+
+// Returns whether a move is legal for the player to move
+// Passing and resigning are always legal
+func (pos *Position) IsLegal(move Intersection) bool {
+	return pos.isLegal(move)
+}
+
+// Returns every legal move on the board, row by row
+// Passing and resigning are always legal, so are not included
+func (pos *Position) LegalMoves() []Intersection {
+	moves := []Intersection{}
+	for i := uint8(0); i < pos.board.size; i++ {
+		for j := uint8(0); j < pos.board.size; j++ {
+			if pos.isLegal(Intersection{i, j}) {
+				moves = append(moves, Intersection{i, j})
+			}
+		}
+	}
+	return moves
+}
+
+// Returns the position after the player to move plays a move
+// The ko rule of the game applies to the new position, and to the
+// positions played from it
+func (pos *Position) Play(move Intersection) (Position, error) {
+	if move == RESIGN {
+		return Position{}, errors.New("resigning does not make a position")
+	}
+	if !pos.isLegal(move) {
+		return Position{}, &IllegalMoveError{pos.blacksTurn, move}
+	}
+	next := Position{
+		board:      pos.board,
+		blacksTurn: !pos.blacksTurn,
+		rules:      pos.rules,
+		previous:   pos.board,
+		captures:   pos.captures,
+	}
+	mover := colorIndex(pos.blacksTurn)
+	if move == PASS {
+		if pos.rules.PassStones {
+			next.captures[1-mover]++
+		}
+	} else {
+		if pos.blacksTurn {
+			next.board.playBlackStone(move)
+		} else {
+			next.board.playWhiteStone(move)
+		}
+		// Prisoners taken by the mover, and any of its own stones lost
+		// to suicide
+		blackBefore, whiteBefore := pos.board.countStones()
+		blackAfter, whiteAfter := next.board.countStones()
+		if pos.blacksTurn {
+			next.captures[mover] += whiteBefore - whiteAfter
+			next.captures[1-mover] += blackBefore + 1 - blackAfter
+		} else {
+			next.captures[mover] += blackBefore - blackAfter
+			next.captures[1-mover] += whiteBefore + 1 - whiteAfter
+		}
+	}
+	// Copy the history, so the old position keeps its own
+	next.history = make([]uint64, len(pos.history), len(pos.history)+1)
+	copy(next.history, pos.history)
+	next.history = append(next.history, positionKey(&next.board, next.blacksTurn, pos.rules.Ko))
+	next.markIllegal(pos.rules.Suicide, next.breaksKo)
+	return next, nil
+}
+
+// Asks if the ko rule forbids the player to move from reaching a board
+func (pos *Position) breaksKo(board *Board) bool {
+	if pos.rules.Ko == SimpleKo {
+		return *board == pos.previous
+	}
+	key := positionKey(board, !pos.blacksTurn, pos.rules.Ko)
+	for _, seen := range pos.history {
+		if seen == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the stones of the chain on an intersection, row by row, or nil
+// if it is empty
+func (pos *Position) Chain(intn Intersection) []Intersection {
+	chain, _ := pos.board.chainAndLiberties(intn)
+	return chain
+}
+
+// Returns the liberties of the chain on an intersection, row by row, or
+// nil if it is empty
+func (pos *Position) Liberties(intn Intersection) []Intersection {
+	_, liberties := pos.board.chainAndLiberties(intn)
+	return liberties
+}
+
+// Finds the stones and the liberties of the chain on an intersection
+func (board *Board) chainAndLiberties(intn Intersection) ([]Intersection, []Intersection) {
+	if board.colorAt(intn) == Empty {
+		return nil, nil
+	}
+	chain := board.chainMask(intn)
+	liberties := board.libertiesMask(&chain)
+	return chain.intersections(), liberties.intersections()
+}
+
+// Returns the prisoners taken by black and by white so far in the game
+// These include stones lost to suicide, and pass stones under rules
+// that have them
+func (pos *Position) Captures() (int, int) {
+	return pos.captures[0], pos.captures[1]
+}
+
+// Asks if the move that led to the position was a pass
+// A move that places a stone always changes the board
+func (pos *Position) lastMovePassed() bool {
+	return len(pos.history) >= 2 && pos.board == pos.previous
+}
+
+// Returns a point the player to move may not play on only because of the
+// ko rule, and whether there is one
+func (pos *Position) KoPoint() (Intersection, bool) {
+	for i := uint8(0); i < pos.board.size; i++ {
+		for j := uint8(0); j < pos.board.size; j++ {
+			intn := Intersection{i, j}
+			if pos.isLegal(intn) || !pos.board.isEmpty(intn) {
+				continue
+			}
+			// Illegal and not suicide, so ko
+			tempBoard := pos.board
+			if pos.blacksTurn {
+				tempBoard.playBlackStone(intn)
+			} else {
+				tempBoard.playWhiteStone(intn)
+			}
+			if !tempBoard.isEmpty(intn) {
+				return intn, true
+			}
+		}
+	}
+	return PASS, false
+}
+
+// Shows the board of the position
+func (pos *Position) String() string {
+	return pos.board.String()
+}
@@ -0,0 +1,166 @@
+package gogame
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// The color of a stone or a player
+// Empty stands for no stone, or for no winner
+type Color uint8
+
+const (
+	Empty Color = iota
+	Black
+	White
+)
+
+func (color Color) String() string {
+	switch color {
+	case Black:
+		return "B"
+	case White:
+		return "W"
+	}
+	return "Empty"
+}
+
+// Returns Black or White
+func colorOf(black bool) Color {
+	if black {
+		return Black
+	}
+	return White
+}
+
+// Returns the other player's color
+func (color Color) Opponent() Color {
+	switch color {
+	case Black:
+		return White
+	case White:
+		return Black
+	}
+	return Empty
+}
+
+// Why a game ended
+type EndReason uint8
+
+const (
+	// Both players passed, and the board was scored
+	TwoPasses EndReason = iota
+	Resignation
+	// The game went on too long, and the board was scored as it stood
+	MoveLimit
+	// A player broke the rules, for example by playing an illegal move
+	Forfeit
+	// A player ran out of time
+	Timeout
+)
+
+func (reason EndReason) String() string {
+	switch reason {
+	case TwoPasses:
+		return "two passes"
+	case Resignation:
+		return "resignation"
+	case MoveLimit:
+		return "move limit"
+	case Forfeit:
+		return "forfeit"
+	case Timeout:
+		return "timeout"
+	}
+	return "unknown"
+}
+
+// The outcome of a game
+type GameResult struct {
+	// Empty if the game was a draw
+	Winner Color
+	// Points the winner won by, komi included
+	// Zero for draws and for games not decided by the score
+	Margin float64
+	Reason EndReason
+	// Black's score, and white's score with komi
+	// Games that are not scored give the whole board to the winner
+	BlackScore float64
+	WhiteScore float64
+	// Number of moves played, passes included
+	Moves int
+	// Prisoners taken by each player
+	BlackCaptures int
+	WhiteCaptures int
+	// The final board with each player's territory filled in
+	Ownership Board
+	// The rules and komi the game was played under
+	Rules Ruleset
+	Komi  float64
+	// What went wrong, for forfeits and timeouts
+	Err error
+}
+
+// The result as written in game records: "B+3.5" for a win on the
+// score, "W+R" by resignation, "B+T" on time, "W+F" by forfeit,
+// and "0" for a draw
+func (result GameResult) String() string {
+	if result.Winner == Empty {
+		return "0"
+	}
+	switch result.Reason {
+	case Resignation:
+		return result.Winner.String() + "+R"
+	case Forfeit:
+		return result.Winner.String() + "+F"
+	case Timeout:
+		return result.Winner.String() + "+T"
+	}
+	return result.Winner.String() + "+" + strconv.FormatFloat(result.Margin, 'f', -1, 64)
+}
+
+// Prints the result with the reason the game ended
+func (result GameResult) PrintOut() {
+	fmt.Printf("Result: %s by %s after %d moves\n", result, result.Reason, result.Moves)
+	if result.Err != nil {
+		fmt.Printf("  %v\n", result.Err)
+	}
+}
+
+// Makes the result of the game as it stands, scoring the last board
+func (game *Game) scoredResult(reason EndReason) GameResult {
+	board := &game.BoardList[len(game.BoardList)-1]
+	result := GameResult{
+		Reason:    reason,
+		Moves:     len(game.Moves),
+		Ownership: board.ownership(),
+		Rules:     game.Config.Rules,
+		Komi:      game.Config.Komi,
+	}
+	result.BlackCaptures, result.WhiteCaptures = game.prisoners()
+	result.BlackScore, result.WhiteScore = game.score()
+	if result.BlackScore > result.WhiteScore {
+		result.Winner = Black
+		result.Margin = result.BlackScore - result.WhiteScore
+	} else if result.WhiteScore > result.BlackScore {
+		result.Winner = White
+		result.Margin = result.WhiteScore - result.BlackScore
+	}
+	return result
+}
+
+// Makes the result of a game the loser did not finish
+// The winner is given the whole board
+func (game *Game) lossResult(loser Color, reason EndReason, err error) GameResult {
+	result := game.scoredResult(reason)
+	result.Winner = loser.Opponent()
+	result.Margin = 0
+	size := float64(game.Config.Size)
+	if loser == Black {
+		result.BlackScore, result.WhiteScore = 0, size*size
+	} else {
+		result.BlackScore, result.WhiteScore = size*size, 0
+	}
+	result.Err = err
+	return result
+}
@@ -0,0 +1,144 @@
+package gogame
+
+import (
+	"math/bits"
+)
+
+// How the score is counted at the end of the game
+type Scoring uint8
+
+const (
+	// Stones on the board plus surrounded empty points
+	AreaScoring Scoring = iota
+	// Surrounded empty points plus prisoners
+	TerritoryScoring
+)
+
+// A set of rules to play a game under
+type Ruleset struct {
+	// Name of the rules, as written in game records
+	Name string
+	// Whether a move may remove its own chain of more than one stone
+	// A single stone suicide changes nothing, and is always illegal
+	Suicide bool
+	// Which repeated positions are illegal
+	Ko KoRule
+	// How the final score is counted
+	Scoring Scoring
+	// Whether passing hands a prisoner to the opponent
+	PassStones bool
+	// Whether the game only ends on two passes when white passes last
+	WhitePassesLast bool
+	// Standard komi for even games
+	Komi float64
+}
+
+// The standard rulesets
+var ChineseRules = Ruleset{
+	Name:    "Chinese",
+	Ko:      PositionalSuperko,
+	Scoring: AreaScoring,
+	Komi:    7.5,
+}
+
+var JapaneseRules = Ruleset{
+	Name:    "Japanese",
+	Ko:      SimpleKo,
+	Scoring: TerritoryScoring,
+	Komi:    6.5,
+}
+
+var AGARules = Ruleset{
+	Name:            "AGA",
+	Ko:              SituationalSuperko,
+	Scoring:         AreaScoring,
+	PassStones:      true,
+	WhitePassesLast: true,
+	Komi:            7.5,
+}
+
+var TrompTaylorRules = Ruleset{
+	Name:    "Tromp-Taylor",
+	Suicide: true,
+	Ko:      PositionalSuperko,
+	Scoring: AreaScoring,
+	Komi:    7.5,
+}
+
+var NewZealandRules = Ruleset{
+	Name:    "NZ",
+	Suicide: true,
+	Ko:      SituationalSuperko,
+	Scoring: AreaScoring,
+	Komi:    7,
+}
+
+// Option to play under the given rules, Chinese rules by default
+func Rules(ruleset Ruleset) GameOption {
+	return func(config *GameConfig) {
+		config.Rules = ruleset
+	}
+}
+
+// Counts the black and white stones on the board
+func (board *Board) countStones() (int, int) {
+	blackStones := 0
+	whiteStones := 0
+	for i := uint8(0); i < board.size; i++ {
+		blackStones += bits.OnesCount32(board.black[i])
+		whiteStones += bits.OnesCount32(board.white[i])
+	}
+	return blackStones, whiteStones
+}
+
+// Counts the empty points surrounded by each color
+// Dead stones, in areas secured by pass-alive chains, are taken off and
+// count as prisoners as well as territory
+func (board *Board) territoryScoring() (int, int) {
+	scoreBoard := board.ownership()
+	blackArea, whiteArea := scoreBoard.countStones()
+	blackStones, whiteStones := board.countStones()
+	blackLive := bitboard(board.black).and(scoreBoard.black)
+	whiteLive := bitboard(board.white).and(scoreBoard.white)
+	blackDead := blackStones - blackLive.count()
+	whiteDead := whiteStones - whiteLive.count()
+	return blackArea - blackLive.count() + whiteDead, whiteArea - whiteLive.count() + blackDead
+}
+
+// Counts the prisoners taken by black and by white so far
+// Includes stones removed by suicide, and pass stones if the rules have them
+func (game *Game) prisoners() (int, int) {
+	blackPrisoners := 0
+	whitePrisoners := 0
+	for _, move := range game.Moves {
+		taken, lost := move.Captures, move.Suicides
+		// A pass gives the opponent a stone
+		if move.Point == PASS && game.Config.Rules.PassStones {
+			lost++
+		}
+		if move.Color == Black {
+			blackPrisoners += taken
+			whitePrisoners += lost
+		} else {
+			whitePrisoners += taken
+			blackPrisoners += lost
+		}
+	}
+	return blackPrisoners, whitePrisoners
+}
+
+// Scores the final board under the rules of the game
+// Returns black and white scores, with komi added to white's
+func (game *Game) score() (float64, float64) {
+	board := &game.BoardList[len(game.BoardList)-1]
+	var blackScore, whiteScore int
+	if game.Config.Rules.Scoring == TerritoryScoring {
+		blackScore, whiteScore = board.territoryScoring()
+		blackPrisoners, whitePrisoners := game.prisoners()
+		blackScore += blackPrisoners
+		whiteScore += whitePrisoners
+	} else {
+		blackScore, whiteScore = board.chineseScoring()
+	}
+	return float64(blackScore), float64(whiteScore) + game.Config.Komi
+}
@@ -0,0 +1,124 @@
+package gogame
+
+import "testing"
+
+// The dead stones position, with a black stone in atari in white's corner
+// that white takes on its second move
+var scoringRows = []string{
+	"XO..XO..X",
+	"XXXXXOOOO",
+	"XO..XO...",
+	"XXXXXOOOO",
+	"XO..XO...",
+	"XXXXXOOOO",
+	"XO..XO...",
+	"XXXXXOOOO",
+	"XO..XO...",
+}
+
+// Plays out the scoring position to the end: black passes, white takes
+// the stone, and both pass
+func scoredGame(t *testing.T, rules Ruleset) Game {
+	game := gameFromRows(scoringRows, rules)
+	for _, move := range []Intersection{PASS, {0, 7}, PASS, PASS} {
+		if err := game.appendMove(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !game.gameOver() {
+		t.Fatalf("game under %s rules not over after two passes", rules.Name)
+	}
+	return game
+}
+
+func TestScoring(t *testing.T) {
+	for _, test := range []struct {
+		rules Ruleset
+		// Scores, komi included
+		black, white float64
+		// Prisoners, pass stones included
+		blackCaptures, whiteCaptures int
+	}{
+		// Black's area is 30 stones, 10 empty points and 5 dead white
+		// stones, white's 22 stones and 14 empty points
+		{ChineseRules, 45, 36 + 7.5, 0, 1},
+		{AGARules, 45, 36 + 7.5, 1, 3},
+		// Black has 10 points of territory and 5 dead stones, counted as
+		// both territory and prisoners, and white 14 points and a prisoner
+		{JapaneseRules, 20, 15 + 6.5, 0, 1},
+	} {
+		game := scoredGame(t, test.rules)
+		result := game.scoredResult(TwoPasses)
+		if result.BlackScore != test.black || result.WhiteScore != test.white {
+			t.Errorf("%s rules score %v to %v, want %v to %v", test.rules.Name, result.BlackScore, result.WhiteScore, test.black, test.white)
+		}
+		if result.BlackCaptures != test.blackCaptures || result.WhiteCaptures != test.whiteCaptures {
+			t.Errorf("%s rules count %d and %d prisoners, want %d and %d", test.rules.Name, result.BlackCaptures, result.WhiteCaptures, test.blackCaptures, test.whiteCaptures)
+		}
+	}
+}
+
+func TestWhitePassesLast(t *testing.T) {
+	for _, test := range []struct {
+		rules Ruleset
+		over  bool
+	}{
+		{ChineseRules, true},
+		{AGARules, false},
+	} {
+		game := gameFromRows(scoringRows, test.rules)
+		for _, move := range []Intersection{{0, 2}, PASS, PASS} {
+			if err := game.appendMove(move); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if game.gameOver() != test.over {
+			t.Errorf("game over %v under %s rules when black passes last, want %v", game.gameOver(), test.rules.Name, test.over)
+		}
+	}
+}
+
+func TestSuicide(t *testing.T) {
+	// Black's two stones in the top left have one liberty, and filling it
+	// loses all three, while black on the bottom right loses just itself
+	rows := []string{
+		"XX.O.....",
+		"OOO......",
+		".........",
+		".........",
+		".........",
+		".........",
+		".........",
+		"........O",
+		".......O.",
+	}
+	multiStone, singleStone := Intersection{0, 2}, Intersection{8, 8}
+	for _, test := range []struct {
+		rules Ruleset
+		legal bool
+	}{
+		{ChineseRules, false},
+		{JapaneseRules, false},
+		{AGARules, false},
+		{NewZealandRules, true},
+		{TrompTaylorRules, true},
+	} {
+		pos := positionFromRows(rows, true, test.rules)
+		if pos.isLegal(multiStone) != test.legal {
+			t.Errorf("suicide of three stones legal %v under %s rules, want %v", pos.isLegal(multiStone), test.rules.Name, test.legal)
+		}
+		if pos.isLegal(singleStone) {
+			t.Errorf("suicide of one stone legal under %s rules", test.rules.Name)
+		}
+		if !test.legal {
+			continue
+		}
+		next, err := pos.Play(multiStone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if black, _ := next.board.countStones(); black != 0 || next.captures[1] != 3 {
+			t.Errorf("%s rules leave %d black stones and %d prisoners after suicide, want 0 and 3", test.rules.Name, black, next.captures[1])
+		}
+	}
+}
@@ -0,0 +1,591 @@
+package gogame
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Option to name the players, as written in game records
+func PlayerNames(black, white string) GameOption {
+	return func(config *GameConfig) {
+		config.BlackName = black
+		config.WhiteName = white
+	}
+}
+
+// Returns the SGF coordinates of an intersection
+// SGF gives the column first, then the row, as letters from a
+// Passes are written as an empty value
+func sgfPoint(intn Intersection) string {
+	if intn == PASS {
+		return ""
+	}
+	return string([]byte{'a' + intn.y, 'a' + intn.x})
+}
+
+// Reads SGF coordinates on a board of the given size
+// An empty value, or tt on small boards, is a pass
+func parseSGFPoint(value string, size uint8) (Intersection, error) {
+	if value == "" || (value == "tt" && size <= 19) {
+		return PASS, nil
+	}
+	if len(value) != 2 {
+		return PASS, fmt.Errorf("bad SGF point %q", value)
+	}
+	intn := Intersection{value[1] - 'a', value[0] - 'a'}
+	if intn.x >= size || intn.y >= size {
+		return PASS, fmt.Errorf("SGF point %q is off the board", value)
+	}
+	return intn, nil
+}
+
+// Escapes the characters that end an SGF text value
+func sgfEscape(text string) string {
+	text = strings.Replace(text, "\\", "\\\\", -1)
+	return strings.Replace(text, "]", "\\]", -1)
+}
+
+// Returns the result of a finished game, as written in SGF
+// such as B+3.5, W+R or 0 for a draw
+// Games that were not played here are scored as they stand
+func (game *Game) resultString() string {
+	if game.Result != nil {
+		return game.Result.String()
+	}
+	return game.scoredResult(TwoPasses).String()
+}
+
+// Writes the game as an SGF FF[4] record
+// Stones on the first board are written as setup stones, and the result
+// is written if the game is over
+// Moves taken back that can be redone are written too
+func (game *Game) WriteSGF(w io.Writer) error {
+	return NewGameTree(game).WriteSGF(w)
+}
+
+// Returns the game as an SGF FF[4] record
+func (game *Game) SGF() string {
+	var record strings.Builder
+	game.WriteSGF(&record)
+	return record.String()
+}
+
+// Writes the tree as an SGF FF[4] record, with its variations
+// The result is written if the main line is over
+func (tree *GameTree) WriteSGF(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	config := &tree.Config
+	fmt.Fprintf(out, "(;FF[4]GM[1]CA[UTF-8]AP[go-player]SZ[%d]", config.Size)
+	fmt.Fprintf(out, "KM[%s]", strconv.FormatFloat(config.Komi, 'f', -1, 64))
+	if config.Rules.Name != "" {
+		fmt.Fprintf(out, "RU[%s]", sgfEscape(config.Rules.Name))
+	}
+	if config.BlackName != "" {
+		fmt.Fprintf(out, "PB[%s]", sgfEscape(config.BlackName))
+	}
+	if config.WhiteName != "" {
+		fmt.Fprintf(out, "PW[%s]", sgfEscape(config.WhiteName))
+	}
+	if config.Handicap > 0 {
+		fmt.Fprintf(out, "HA[%d]", config.Handicap)
+	}
+	mainLine := tree.MainLine()
+	if game := tree.Game(mainLine[len(mainLine)-1]); game.Result != nil || game.gameOver() {
+		fmt.Fprintf(out, "RE[%s]", game.resultString())
+	}
+	// Setup stones
+	first := &tree.Root.Board
+	blackSetup := ""
+	whiteSetup := ""
+	for i := uint8(0); i < first.size; i++ {
+		for j := uint8(0); j < first.size; j++ {
+			intn := Intersection{i, j}
+			if first.isBlackStone(intn) {
+				blackSetup += "[" + sgfPoint(intn) + "]"
+			} else if first.isWhiteStone(intn) {
+				whiteSetup += "[" + sgfPoint(intn) + "]"
+			}
+		}
+	}
+	if blackSetup != "" {
+		fmt.Fprintf(out, "AB%s", blackSetup)
+	}
+	if whiteSetup != "" {
+		fmt.Fprintf(out, "AW%s", whiteSetup)
+	}
+	if tree.whiteFirst {
+		fmt.Fprint(out, "PL[W]")
+	}
+	if config.Time.System != NoClock {
+		fmt.Fprintf(out, "TM[%s]", seconds(config.Time.MainTime))
+		if overtime := config.Time.overtime(); overtime != "" {
+			fmt.Fprintf(out, "OT[%s]", sgfEscape(overtime))
+		}
+	}
+	if tree.Root.Comment != "" {
+		fmt.Fprintf(out, "C[%s]", sgfEscape(tree.Root.Comment))
+	}
+	fmt.Fprintln(out)
+	tree.writeSGFChildren(out, tree.Root, 0, [2]Clock{newClock(config.Time), newClock(config.Time)})
+	fmt.Fprintln(out, ")")
+	return out.Flush()
+}
+
+// Returns the tree as an SGF FF[4] record
+func (tree *GameTree) SGF() string {
+	var record strings.Builder
+	tree.WriteSGF(&record)
+	return record.String()
+}
+
+// Writes the moves after a node, a single line as it is and each of
+// several variations in brackets
+// The node is the numberth of its line, and the clocks are those after it
+func (tree *GameTree) writeSGFChildren(out *bufio.Writer, node *TreeNode, number int, clocks [2]Clock) {
+	if len(node.Children) == 1 {
+		tree.writeSGFMoves(out, node.Children[0], number+1, clocks)
+		return
+	}
+	for _, child := range node.Children {
+		fmt.Fprint(out, "(")
+		tree.writeSGFMoves(out, child, number+1, clocks)
+		fmt.Fprint(out, ")")
+	}
+}
+
+// Writes the move of a node, with the time left after it if there are
+// clocks, then the moves after it
+// The move is the numberth of its line, and the clocks are those before it
+func (tree *GameTree) writeSGFMoves(out *bufio.Writer, node *TreeNode, number int, clocks [2]Clock) {
+	move := node.Move
+	color := move.Color.String()
+	fmt.Fprintf(out, ";%s[%s]", color, sgfPoint(move.Point))
+	if tree.Config.Time.System != NoClock {
+		clock := &clocks[colorIndex(move.Color == Black)]
+		clock.spend(move.Time)
+		fmt.Fprintf(out, "%sL[%s]", color, seconds(clock.Remaining))
+		if clock.Overtime && tree.Config.Time.System == ByoYomiTime {
+			fmt.Fprintf(out, "O%s[%d]", color, clock.Periods)
+		} else if clock.Overtime && tree.Config.Time.System == CanadianTime {
+			fmt.Fprintf(out, "O%s[%d]", color, clock.Stones)
+		}
+	}
+	if node.Comment != "" {
+		fmt.Fprintf(out, "C[%s]", sgfEscape(node.Comment))
+	}
+	// Ten moves to a line
+	if number%10 == 0 {
+		fmt.Fprintln(out)
+	}
+	tree.writeSGFChildren(out, node, number, clocks)
+}
+
+// A node of a parsed SGF game tree
+type sgfNode struct {
+	props    map[string][]string
+	children []*sgfNode
+}
+
+// Returns the first value of a property, or the empty string
+func (node *sgfNode) prop(id string) string {
+	values := node.props[id]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Parser for the text of an SGF file
+type sgfParser struct {
+	data string
+	pos  int
+}
+
+var errSGFEnd = errors.New("unexpected end of SGF")
+
+// Skips whitespace, and returns the next character without using it
+func (parser *sgfParser) peek() (byte, error) {
+	for parser.pos < len(parser.data) {
+		c := parser.data[parser.pos]
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+			return c, nil
+		}
+		parser.pos++
+	}
+	return 0, errSGFEnd
+}
+
+// Parses a game tree: ( sequence of nodes, then variations )
+// Returns the first node of the sequence, each later node being the only
+// child of the one before, and variations children of the last
+func (parser *sgfParser) parseTree() (*sgfNode, error) {
+	c, err := parser.peek()
+	if err != nil {
+		return nil, err
+	}
+	if c != '(' {
+		return nil, fmt.Errorf("expected ( at SGF offset %d", parser.pos)
+	}
+	parser.pos++
+	var first, last *sgfNode
+	for {
+		c, err := parser.peek()
+		if err != nil {
+			return nil, err
+		}
+		switch c {
+		case ';':
+			parser.pos++
+			node, err := parser.parseProperties()
+			if err != nil {
+				return nil, err
+			}
+			if first == nil {
+				first = node
+			} else {
+				last.children = append(last.children, node)
+			}
+			last = node
+		case '(':
+			if last == nil {
+				return nil, fmt.Errorf("variation before first node at SGF offset %d", parser.pos)
+			}
+			variation, err := parser.parseTree()
+			if err != nil {
+				return nil, err
+			}
+			last.children = append(last.children, variation)
+		case ')':
+			parser.pos++
+			if first == nil {
+				return nil, fmt.Errorf("empty game tree at SGF offset %d", parser.pos)
+			}
+			return first, nil
+		default:
+			return nil, fmt.Errorf("unexpected %q at SGF offset %d", c, parser.pos)
+		}
+	}
+}
+
+// Parses the properties of a node, after its ;
+func (parser *sgfParser) parseProperties() (*sgfNode, error) {
+	node := &sgfNode{props: make(map[string][]string)}
+	for {
+		c, err := parser.peek()
+		if err != nil {
+			return nil, err
+		}
+		if c < 'A' || c > 'Z' {
+			return node, nil
+		}
+		start := parser.pos
+		for parser.pos < len(parser.data) && parser.data[parser.pos] >= 'A' && parser.data[parser.pos] <= 'Z' {
+			parser.pos++
+		}
+		id := parser.data[start:parser.pos]
+		values := []string{}
+		for {
+			c, err := parser.peek()
+			if err != nil {
+				return nil, err
+			}
+			if c != '[' {
+				break
+			}
+			parser.pos++
+			value, err := parser.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("property %s has no value at SGF offset %d", id, parser.pos)
+		}
+		node.props[id] = append(node.props[id], values...)
+	}
+}
+
+// Parses a property value, after its [
+func (parser *sgfParser) parseValue() (string, error) {
+	var value strings.Builder
+	for parser.pos < len(parser.data) {
+		c := parser.data[parser.pos]
+		parser.pos++
+		if c == ']' {
+			return value.String(), nil
+		}
+		if c == '\\' && parser.pos < len(parser.data) {
+			c = parser.data[parser.pos]
+			parser.pos++
+			// An escaped line break is removed
+			if c == '\n' || c == '\r' {
+				continue
+			}
+		}
+		value.WriteByte(c)
+	}
+	return "", errSGFEnd
+}
+
+// Finds the standard ruleset with the given SGF name
+// Unknown names give Chinese rules
+func rulesetNamed(name string) Ruleset {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "japanese":
+		return JapaneseRules
+	case "aga":
+		return AGARules
+	case "nz", "new zealand":
+		return NewZealandRules
+	case "tromp-taylor", "tromptaylor", "tromp taylor":
+		return TrompTaylorRules
+	}
+	return ChineseRules
+}
+
+// Reads an SGF record into a game, following the main line of play
+// Setup stones must come before the first move
+// Moves out of turn are recorded after a pass by the other player
+// The game has no player functions, so cannot be played on
+func ReadSGF(r io.Reader) (Game, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Game{}, err
+	}
+	return ParseSGF(string(data))
+}
+
+// Parses the text of an SGF record into a game, as ReadSGF
+func ParseSGF(data string) (Game, error) {
+	tree, err := ParseSGFTree(data)
+	if err != nil {
+		return Game{}, err
+	}
+	mainLine := tree.MainLine()
+	return tree.Game(mainLine[len(mainLine)-1]), nil
+}
+
+// Reads an SGF record into a game tree, with all its variations
+// Setup stones must come before the first move, and are put on the root
+// Moves out of turn are recorded after a pass by the other player
+// Nodes without a move are joined to the node before, keeping comments
+// The clock is read from TM and OT, and the time each move took from the
+// time left after it
+func ReadSGFTree(r io.Reader) (*GameTree, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSGFTree(string(data))
+}
+
+// Parses the text of an SGF record into a game tree, as ReadSGFTree
+func ParseSGFTree(data string) (*GameTree, error) {
+	parser := sgfParser{data: data}
+	root, err := parser.parseTree()
+	if err != nil {
+		return nil, err
+	}
+	if gm := root.prop("GM"); gm != "" && gm != "1" {
+		return nil, fmt.Errorf("SGF game type %s is not go", gm)
+	}
+
+	// Settings from the root node
+	config := GameConfig{Size: 19, Rules: ChineseRules}
+	if sz := root.prop("SZ"); sz != "" {
+		size, err := strconv.Atoi(strings.TrimSpace(sz))
+		if err != nil || size < int(MIN_SIZE) || size > int(MAX_SIZE) {
+			return nil, fmt.Errorf("unsupported SGF board size %q", sz)
+		}
+		config.Size = uint8(size)
+	}
+	if ru := root.prop("RU"); ru != "" {
+		config.Rules = rulesetNamed(ru)
+	}
+	config.Komi = config.Rules.Komi
+	if km := root.prop("KM"); km != "" {
+		komi, err := strconv.ParseFloat(strings.TrimSpace(km), 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad SGF komi %q", km)
+		}
+		config.Komi = komi
+	}
+	config.komiSet = true
+	if ha := root.prop("HA"); ha != "" {
+		handicap, err := strconv.Atoi(strings.TrimSpace(ha))
+		if err != nil {
+			return nil, fmt.Errorf("bad SGF handicap %q", ha)
+		}
+		config.Handicap = handicap
+		config.FreeHandicap = true
+	}
+	config.BlackName = root.prop("PB")
+	config.WhiteName = root.prop("PW")
+	if tm := root.prop("TM"); tm != "" {
+		control, err := parseTimeControl(tm, root.prop("OT"))
+		if err != nil {
+			return nil, err
+		}
+		config.Time = control
+	}
+
+	tree := &GameTree{Config: config, Root: &TreeNode{Board: newBoard(config.Size)}}
+	clocks := [2]Clock{newClock(config.Time), newClock(config.Time)}
+	if err := tree.readSGFNode(tree.Root, root, clocks); err != nil {
+		return nil, err
+	}
+	// The result is that of the main line
+	mainLine := tree.MainLine()
+	end := mainLine[len(mainLine)-1]
+	game := tree.Game(end)
+	game.readSGFResult(root.prop("RE"))
+	end.Result = game.Result
+	return tree, nil
+}
+
+// Adds the move of a parsed SGF node after a tree node, then the nodes
+// after it
+// The clocks are those after the tree node, and give the time each move
+// took from the time left after it
+func (tree *GameTree) readSGFNode(node *TreeNode, sgf *sgfNode, clocks [2]Clock) error {
+	size := tree.Config.Size
+	// Setup stones and player to move, before any move has been read
+	setup := node == tree.Root && len(node.Children) == 0
+	for _, id := range []string{"AB", "AW", "AE"} {
+		for _, value := range sgf.props[id] {
+			if !setup {
+				return errors.New("SGF setup stones after the first move are not supported")
+			}
+			intn, err := parseSGFPoint(value, size)
+			if err != nil || intn == PASS {
+				return fmt.Errorf("bad SGF setup point %q", value)
+			}
+			board := &tree.Root.Board
+			if id == "AB" {
+				board.placeBlackStone(intn)
+			} else if id == "AW" {
+				board.placeWhiteStone(intn)
+			} else {
+				board.clearIntersection(intn)
+			}
+		}
+	}
+	if pl := sgf.prop("PL"); pl != "" && setup {
+		tree.whiteFirst = pl == "W" || pl == "w"
+	}
+	// The move
+	for _, color := range []string{"B", "W"} {
+		values, ok := sgf.props[color]
+		if !ok {
+			continue
+		}
+		intn, err := parseSGFPoint(values[0], size)
+		if err != nil {
+			return err
+		}
+		if setup {
+			tree.whiteFirst = color == "W"
+			setup = false
+		}
+		if tree.toMove(node) != colorOf(color == "B") {
+			board := node.Board
+			move, _ := playMove(&board, tree.toMove(node), PASS)
+			node = node.addChild(move, board)
+		}
+		board := node.Board
+		move, err := playMove(&board, tree.toMove(node), intn)
+		if err != nil {
+			return err
+		}
+		if left := sgf.prop(color + "L"); left != "" && tree.Config.Time.System != NoClock {
+			move.Time, err = readSGFTime(&clocks[colorIndex(color == "B")], left, sgf.prop("O"+color))
+			if err != nil {
+				return err
+			}
+		}
+		node = node.addChild(move, board)
+	}
+	if comment := sgf.prop("C"); comment != "" {
+		if node.Comment != "" {
+			node.Comment += "\n"
+		}
+		node.Comment += comment
+	}
+	for _, child := range sgf.children {
+		if err := tree.readSGFNode(node, child, clocks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reads how long a move took from the time left after it and any
+// overtime periods or stones left, as written in BL and OB or WL and OW,
+// and takes it off the player's clock
+func readSGFTime(clock *Clock, left, overtime string) (time.Duration, error) {
+	remaining, err := parseSeconds(left)
+	if err != nil {
+		return 0, fmt.Errorf("bad SGF time left %q", left)
+	}
+	periods := -1
+	if overtime != "" {
+		periods, err = strconv.Atoi(strings.TrimSpace(overtime))
+		if err != nil {
+			return 0, fmt.Errorf("bad SGF overtime %q", overtime)
+		}
+	}
+	return clock.spendUntil(remaining, periods), nil
+}
+
+// Keeps the recorded result, such as W+R or B+3.5
+// A scored result keeps its winner and margin, though the board may be
+// scored differently by our rules
+// Results that cannot be read, such as Void or ?, are left out
+func (game *Game) readSGFResult(re string) {
+	re = strings.ToUpper(strings.TrimSpace(re))
+	if re == "0" || re == "DRAW" {
+		result := game.scoredResult(TwoPasses)
+		result.Winner, result.Margin = Empty, 0
+		game.Result = &result
+		return
+	}
+	if len(re) < 3 || re[1] != '+' {
+		return
+	}
+	var loser Color
+	switch re[0] {
+	case 'B':
+		loser = White
+	case 'W':
+		loser = Black
+	default:
+		return
+	}
+	var reason EndReason
+	switch re[2:] {
+	case "R", "RESIGN":
+		reason = Resignation
+	case "T", "TIME":
+		reason = Timeout
+	case "F", "FORFEIT":
+		reason = Forfeit
+	default:
+		margin, err := strconv.ParseFloat(re[2:], 64)
+		if err != nil || margin <= 0 {
+			return
+		}
+		result := game.scoredResult(TwoPasses)
+		result.Winner, result.Margin = loser.Opponent(), margin
+		game.Result = &result
+		return
+	}
+	result := game.lossResult(loser, reason, nil)
+	game.Result = &result
+}
@@ -0,0 +1,76 @@
+package gogame
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSGFKeepsRecordedResult(t *testing.T) {
+	for _, test := range []struct {
+		record string
+		want   string
+	}{
+		{"(;GM[1]FF[4]SZ[9]KM[6.5]RE[B+3.5];B[cc];W[gg];B[];W[])", "RE[B+3.5]"},
+		{"(;GM[1]FF[4]SZ[9]KM[6.5]RE[B+3.5];B[cc];W[gg])", "RE[B+3.5]"},
+		{"(;GM[1]FF[4]SZ[9]KM[6.5]RE[W+12];B[cc];W[gg])", "RE[W+12]"},
+		{"(;GM[1]FF[4]SZ[9]KM[6.5]RE[W+R];B[cc];W[gg])", "RE[W+R]"},
+		{"(;GM[1]FF[4]SZ[9]KM[6.5]RE[0];B[cc];W[gg])", "RE[0]"},
+	} {
+		game, err := ParseSGF(test.record)
+		if err != nil {
+			t.Fatalf("%s: %v", test.record, err)
+		}
+		written := game.SGF()
+		if !strings.Contains(written, test.want) {
+			t.Errorf("%s written as %s, want %s", test.record, written, test.want)
+		}
+		// And back again
+		again, err := ParseSGF(written)
+		if err != nil {
+			t.Fatalf("%s: %v", written, err)
+		}
+		if again.SGF() != written {
+			t.Errorf("%s changed when read back, to %s", written, again.SGF())
+		}
+	}
+}
+
+func TestSGFKeepsClocks(t *testing.T) {
+	second := time.Second
+	for _, test := range []struct {
+		clock GameOption
+		// Times the record can give exactly, as time spent within a
+		// byo-yomi period is not written
+		times []time.Duration
+	}{
+		{AbsoluteClock(60 * second), []time.Duration{3 * second, 1500 * time.Millisecond, 7 * second, 0}},
+		{FischerClock(10*second, 5*second), []time.Duration{8 * second, 2 * second, 250 * time.Millisecond, 9 * second}},
+		{ByoYomiClock(10*second, 5*second, 3), []time.Duration{4 * second, 3 * second, 6 * second, 12 * second}},
+		{CanadianClock(10*second, 20*second, 3), []time.Duration{4 * second, 6 * second, 5 * second, 7 * second, 6 * second, 9 * second}},
+	} {
+		game := MakeGame(FuncPlayer("black", RandomPlayer), FuncPlayer("white", RandomPlayer), BoardSize(9), test.clock)
+		for k, used := range test.times {
+			if err := game.appendMove(Intersection{uint8(k), uint8(k)}); err != nil {
+				t.Fatal(err)
+			}
+			game.Moves[k].Time = used
+		}
+		written := game.SGF()
+		again, err := ParseSGF(written)
+		if err != nil {
+			t.Fatalf("%s: %v", written, err)
+		}
+		if again.Config.Time != game.Config.Time {
+			t.Errorf("%s read back with clock %+v, want %+v", written, again.Config.Time, game.Config.Time)
+		}
+		for k, move := range again.Moves {
+			if move.Time != test.times[k] {
+				t.Errorf("%s read back with move %d taking %s, want %s", written, k, move.Time, test.times[k])
+			}
+		}
+		if again.SGF() != written {
+			t.Errorf("%s changed when read back, to %s", written, again.SGF())
+		}
+	}
+}
@@ -18,7 +18,7 @@ func (pos *Position) worseThanPass(intn Intersection) bool {
 // Is this an eye for the person on move?
 func (pos *Position) isEye(intn Intersection) bool {
 
-	for _, adjIntn := range intn.adjacents() {
+	for _, adjIntn := range intn.adjacents(pos.board.size) {
 		if pos.blacksTurn {
 			if !pos.board.isBlackStone(adjIntn) {
 				return false
@@ -30,9 +30,9 @@ func (pos *Position) isEye(intn Intersection) bool {
 		}
 	}
 	tempBoard := pos.board
-	tempBoard.removeChain(intn.adjacents()[0])
+	tempBoard.removeChain(intn.adjacents(pos.board.size)[0])
 
-	for _, adjIntn := range intn.adjacents() {
+	for _, adjIntn := range intn.adjacents(pos.board.size) {
 		if !tempBoard.isEmpty(adjIntn) {
 			return false
 		}
@@ -58,13 +58,655 @@ func (pos *Position) isCapture(intn Intersection) bool {
 }
 
 // Possible states for single chains: Consider a black chain
-// Dead:   black can not save the group, even on blacks turn.
-// Danger: black can only save the group if it is blacks turn.
-// Alive:  black can save the group if they choose.
-// Unconditionally Alive:   white cannot possibly kill the group.
+type ChainStatus uint8
 
-/*
+const (
+	// Black can not save the group, even on blacks turn
+	Dead ChainStatus = iota
+	// Black can only save the group if it is blacks turn
+	Danger
+	// Black can save the group if they choose
+	Alive
+	// White cannot possibly kill the group
+	UnconditionallyAlive
+)
+
+func (status ChainStatus) String() string {
+	switch status {
+	case Dead:
+		return "dead"
+	case Danger:
+		return "danger"
+	case Alive:
+		return "alive"
+	case UnconditionallyAlive:
+		return "unconditionally alive"
+	}
+	return "unknown"
+}
+
+// Moves read ahead by the classifier before a fight is left unsettled
+const STATUS_DEPTH = 8
+
+// Positions the classifier searches in each reading
+const STATUS_NODES = 20000
+
+// Liberties a chain must reach to have escaped
+const ESCAPE_LIBERTIES = 4
+
+// Classifies the chain on an intersection
+// Unconditional life is found by Benson's algorithm, the other states by
+// reading up to STATUS_DEPTH moves ahead, under simple ko
+// A fight that is not read out in time is taken to be in danger
+// Requires that the Intersection intn be a stone
+func (pos *Position) Status(intn Intersection) ChainStatus {
+	if pos.board.colorAt(intn) == Empty {
+		panic("Tried to find status of empty intersection")
+	}
+	if pos.board.isUnconditionallyAlive(intn) {
+		return UnconditionallyAlive
+	}
+	reader := newReader(&pos.board, intn, STATUS_NODES)
+	if attack := reader.read(pos, true, STATUS_DEPTH); attack.Settled && !attack.Success {
+		return Alive
+	}
+	if defence := reader.read(pos, false, STATUS_DEPTH); defence.Settled && !defence.Success {
+		return Dead
+	}
+	return Danger
+}
+
+// The outcome of reading a fight over a chain
+type Reading struct {
+	// Whether the side moving first gets its way: the attacker capturing
+	// the chain, or the defender saving it
+	Success bool
+	// Whether the fight was read out, rather than cut short by the depth
+	// or the node budget
+	Settled bool
+	// The best first move found, such as the move that captures or saves
+	// the chain, PASS if the defender need not answer
+	Move Intersection
+	// Positions searched
+	Nodes int
+}
+
+// Reads whether the opponent of the chain on an intersection, moving
+// first, can capture it within depth moves, searching at most nodes
+// positions
+// Requires that the Intersection intn be a stone
+func (pos *Position) ReadAttack(intn Intersection, depth, nodes int) Reading {
+	if pos.board.colorAt(intn) == Empty {
+		panic("Tried to read attack on empty intersection")
+	}
+	reader := newReader(&pos.board, intn, nodes)
+	return reader.read(pos, true, depth)
+}
+
+// Reads whether the owner of the chain on an intersection, moving first,
+// can save it from capture within depth moves, searching at most nodes
+// positions
+// Requires that the Intersection intn be a stone
+func (pos *Position) ReadDefence(intn Intersection, depth, nodes int) Reading {
+	if pos.board.colorAt(intn) == Empty {
+		panic("Tried to read defence of empty intersection")
+	}
+	reader := newReader(&pos.board, intn, nodes)
+	return reader.read(pos, false, depth)
+}
+
+// Values of a fight for the attacker
+const (
+	readEscaped  = -1
+	readUnknown  = 0
+	readCaptured = 1
+)
+
+// Kinds of value kept in the transposition table: alpha-beta cuts leave
+// only a bound on the true value
+const (
+	exactValue = iota
+	lowerBound
+	upperBound
+)
+
+// A position searched by the reader
+type readKey struct {
+	hash           uint64
+	ko             Intersection
+	attackerToMove bool
+}
+
+// What the reader found about a position, and how deep it looked
+type readEntry struct {
+	value int
+	bound int
+	depth int
+	move  Intersection
+}
+
+// An alpha-beta search of the fight over a chain, with a transposition
+// table keyed by board hash, kept between readings of the same chain
+type reader struct {
+	target       Intersection
+	blackAttacks bool
+	nodes        int
+	budget       int
+	table        map[readKey]readEntry
+}
+
+// Makes a reader for the chain on an intersection, which searches at
+// most budget positions in each reading
+func newReader(board *Board, intn Intersection, budget int) *reader {
+	return &reader{
+		target:       intn,
+		blackAttacks: !board.isBlackStone(intn),
+		budget:       budget,
+		table:        make(map[readKey]readEntry),
+	}
+}
+
+// Reads the fight from the position, the attacker or the defender moving
+// first
+func (r *reader) read(pos *Position, attackerFirst bool, depth int) Reading {
+	// The ko only binds the player to move, the other could play there
+	// after a move elsewhere
+	ko, _ := pos.KoPoint()
+	if pos.blacksTurn != (attackerFirst == r.blackAttacks) {
+		ko = PASS
+	}
+	r.nodes = 0
+	value, move := r.search(&pos.board, attackerFirst, ko, depth, readEscaped, readCaptured)
+	reading := Reading{Settled: value != readUnknown, Move: move, Nodes: r.nodes}
+	if attackerFirst {
+		reading.Success = value == readCaptured
+	} else {
+		reading.Success = value == readEscaped
+	}
+	return reading
+}
+
+// Searches the fight for its value to the attacker, within the window
+// from alpha to beta, and the best move found
+// The attacker plays on the chain's liberties. The defender may extend
+// on a liberty, capture a chain next to it that is in atari, or play
+// elsewhere, which lifts any ko
+func (r *reader) search(board *Board, attackerToMove bool, ko Intersection, depth, alpha, beta int) (int, Intersection) {
+	if board.isEmpty(r.target) {
+		return readCaptured, PASS
+	}
+	chain := board.chainMask(r.target)
+	liberties := board.libertiesMask(&chain)
+	if liberties.count() >= ESCAPE_LIBERTIES {
+		return readEscaped, PASS
+	}
+	if depth == 0 || r.nodes >= r.budget {
+		return readUnknown, PASS
+	}
+	r.nodes++
+
+	key := readKey{board.hash, ko, attackerToMove}
+	entry, seen := r.table[key]
+	if seen && entry.usable(depth, alpha, beta) {
+		return entry.value, entry.move
+	}
+
+	moves := liberties.intersections()
+	if !attackerToMove {
+		moves = append(moves, board.capturesNextTo(&chain, r.blackAttacks)...)
+		moves = append(moves, PASS)
+	}
+	// Try the best move of an earlier search first
+	if seen {
+		for k, move := range moves {
+			if move == entry.move {
+				copy(moves[1:k+1], moves[:k])
+				moves[0] = move
+				break
+			}
+		}
+	}
+
+	best, bestMove := readCaptured, PASS
+	if attackerToMove {
+		best = readEscaped
+	}
+	found := false
+	low, high := alpha, beta
+	for _, move := range moves {
+		var value int
+		if move == PASS {
+			value, _ = r.search(board, !attackerToMove, PASS, depth-1, low, high)
+		} else {
+			next, nextKo, ok := board.readMove(move, attackerToMove == r.blackAttacks, ko)
+			if !ok {
+				continue
+			}
+			value, _ = r.search(&next, !attackerToMove, nextKo, depth-1, low, high)
+		}
+		if !found || (attackerToMove && value > best) || (!attackerToMove && value < best) {
+			best, bestMove, found = value, move, true
+		}
+		if attackerToMove && best > low {
+			low = best
+		} else if !attackerToMove && best < high {
+			high = best
+		}
+		if low >= high {
+			break
+		}
+	}
+
+	// A search cut short by the budget is not worth keeping
+	if r.nodes < r.budget {
+		entry = readEntry{value: best, bound: exactValue, depth: depth, move: bestMove}
+		if best <= alpha {
+			entry.bound = upperBound
+		} else if best >= beta {
+			entry.bound = lowerBound
+		}
+		r.table[key] = entry
+	}
+	return best, bestMove
+}
+
+// Asks if a table entry settles a search to the given depth, within the
+// window from alpha to beta
+// Captures and escapes that were read out hold at any depth
+func (entry *readEntry) usable(depth, alpha, beta int) bool {
+	proven := (entry.value == readCaptured && entry.bound != upperBound) ||
+		(entry.value == readEscaped && entry.bound != lowerBound)
+	if entry.depth < depth && !proven {
+		return false
+	}
+	switch entry.bound {
+	case lowerBound:
+		return entry.value >= beta
+	case upperBound:
+		return entry.value <= alpha
+	}
+	return true
+}
+
+// Returns the last liberties of the attacking chains next to a chain
+// that are in atari, where the chain's owner could capture them
+func (board *Board) capturesNextTo(chain *bitboard, blackAttacks bool) []Intersection {
+	moves := []Intersection{}
+	attackers := bitboard(board.white)
+	if blackAttacks {
+		attackers = board.black
+	}
+	grown := chain.dilate(board.size)
+	attackers = grown.and(attackers)
+	for !attackers.isZero() {
+		attacker := board.chainMask(attackers.first())
+		attackerLiberties := board.libertiesMask(&attacker)
+		if attackerLiberties.count() == 1 {
+			moves = append(moves, attackerLiberties.first())
+		}
+		for i := range attackers {
+			attackers[i] &^= attacker[i]
+		}
+	}
+	return moves
+}
+
+// Plays a move while reading, if it is legal under simple ko
+// Returns the board after it, and the point the opponent may not take
+// back a ko on, or PASS
+func (board *Board) readMove(intn Intersection, black bool, ko Intersection) (Board, Intersection, bool) {
+	if intn == ko || !board.isEmpty(intn) {
+		return *board, PASS, false
+	}
+	next := *board
+	if black {
+		next.playBlackStone(intn)
+	} else {
+		next.playWhiteStone(intn)
+	}
+	// Suicide
+	if next.isEmpty(intn) {
+		return *board, PASS, false
+	}
+	// A single stone taking a single stone, and left in atari, is a ko
+	blackBefore, whiteBefore := board.countStones()
+	blackAfter, whiteAfter := next.countStones()
+	captured := whiteBefore - whiteAfter
+	if !black {
+		captured = blackBefore - blackAfter
+	}
+	nextKo := PASS
+	if captured == 1 {
+		chain := next.chainMask(intn)
+		liberties := next.libertiesMask(&chain)
+		if chain.count() == 1 && liberties.count() == 1 {
+			nextKo = liberties.first()
+		}
+	}
+	return next, nextKo, true
+}
+
+// Finds the chains of a color that can never be captured, even if the
+// color passes every move, by Benson's algorithm
+// Returns the stones of those chains, and the regions they secure: those
+// enclosed by them alone, every empty point of which is one of their
+// liberties, so the opponent can never make an eye there
+func (board *Board) passAlive(black bool) (bitboard, bitboard) {
+	own := bitboard(board.white)
+	if black {
+		own = board.black
+	}
+	empty := board.emptyMask()
+	var others bitboard
+	for i := uint8(0); i < board.size; i++ {
+		others[i] = ^own[i] & (uint32(1)<<board.size - 1)
+	}
+	chains := own.components(board.size)
+	// The regions the chains enclose, of empty points and opponent stones
+	regions := others.components(board.size)
+
+	// A region is vital to a chain next to it if all its empty points are
+	// liberties of the chain
+	grownChains := make([]bitboard, len(chains))
+	for c := range chains {
+		grownChains[c] = chains[c].dilate(board.size)
+	}
+	borders := make([][]bool, len(regions))
+	vital := make([][]bool, len(regions))
+	for r := range regions {
+		borders[r] = make([]bool, len(chains))
+		vital[r] = make([]bool, len(chains))
+		regionEmpty := regions[r].and(empty)
+		for c := range chains {
+			if touching := grownChains[c].and(regions[r]); !touching.isZero() {
+				borders[r][c] = true
+				vital[r][c] = regionEmpty.and(grownChains[c]) == regionEmpty
+			}
+		}
+	}
+
+	// Drop chains with fewer than two vital regions, and regions next to
+	// a dropped chain, until none are left to drop
+	alive := make([]bool, len(chains))
+	for c := range alive {
+		alive[c] = true
+	}
+	healthy := make([]bool, len(regions))
+	for r := range healthy {
+		healthy[r] = true
+	}
+	for changed := true; changed; {
+		changed = false
+		for c := range chains {
+			if !alive[c] {
+				continue
+			}
+			vitalRegions := 0
+			for r := range regions {
+				if healthy[r] && vital[r][c] {
+					vitalRegions++
+				}
+			}
+			if vitalRegions < 2 {
+				alive[c] = false
+				changed = true
+			}
+		}
+		for r := range regions {
+			if !healthy[r] {
+				continue
+			}
+			for c := range chains {
+				if borders[r][c] && !alive[c] {
+					healthy[r] = false
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	var stones, area bitboard
+	for c := range chains {
+		if alive[c] {
+			for i := range stones {
+				stones[i] |= chains[c][i]
+			}
+		}
+	}
+	grown := stones.dilate(board.size)
+	for r := range regions {
+		regionEmpty := regions[r].and(empty)
+		enclosed := false
+		for c := range chains {
+			enclosed = enclosed || borders[r][c]
+		}
+		if healthy[r] && enclosed && regionEmpty.and(grown) == regionEmpty {
+			for i := range area {
+				area[i] |= regions[r][i]
+			}
+		}
+	}
+	return stones, area
+}
+
+// Asks if the chain on the intersection can never be captured
 func (board *Board) isUnconditionallyAlive(intn Intersection) bool {
+	alive, _ := board.passAlive(board.isBlackStone(intn))
+	return alive.has(intn)
+}
+
+// Returns the stones of a color that can never be captured, and the
+// points they secure, row by row
+// Any opponent stones in those points are dead, and the opponent can
+// never live there
+func (pos *Position) PassAlive(color Color) ([]Intersection, []Intersection) {
+	if color == Empty {
+		return nil, nil
+	}
+	stones, area := pos.board.passAlive(color == Black)
+	return stones.intersections(), area.intersections()
+}
+
+// Returns the points where playing is no better than passing: those
+// worseThanPass finds, and the areas secured by either color
+func (pos *Position) pointlessMoves() bitboard {
+	_, pointless := pos.board.passAlive(true)
+	_, whiteArea := pos.board.passAlive(false)
+	for i := range pointless {
+		pointless[i] |= whiteArea[i]
+	}
+	for i := uint8(0); i < pos.board.size; i++ {
+		for j := uint8(0); j < pos.board.size; j++ {
+			intn := Intersection{i, j}
+			if !pointless.has(intn) && pos.worseThanPass(intn) {
+				pointless[i] |= 1 << j
+			}
+		}
+	}
+	return pointless
+}
+
+// Returns black's winning margin, or one with the same sign, if the
+// chains each color has made pass-alive and the areas they secure
+// already decide a game under area scoring
+func settledMargin(board *Board, komi float64) (float64, bool) {
+	total := float64(int(board.size) * int(board.size))
+	blackStones, blackArea := board.passAlive(true)
+	whiteStones, whiteArea := board.passAlive(false)
+	black := float64(blackStones.count() + blackArea.count())
+	white := float64(whiteStones.count() + whiteArea.count())
+	// At worst every other point goes to the opponent
+	if margin := black - (total - black) - komi; margin > 0 {
+		return margin, true
+	}
+	if margin := (total - white) - white - komi; margin < 0 {
+		return margin, true
+	}
+	return 0, false
+}
+
+// Moves read along a ladder before it is taken to fail
+const LADDER_DEPTH = 4 * int(MAX_SIZE)
+
+// The outcome of reading a ladder against a chain
+type Ladder struct {
+	// Whether the chain is captured
+	Works bool
+	// The moves of the line read, in turn from the first: the capturing
+	// sequence if the ladder works, else the line on which the chain
+	// escapes
+	Moves []Intersection
+	// Stones of the chain's color that the chain runs into and escapes
+	// by, if the ladder fails
+	Breakers []Intersection
+}
+
+// Reads whether the chain on an intersection is captured in a ladder,
+// the opponent giving atari on every move
+// A chain in atari moves first, a chain with two liberties is attacked
+// first, and a chain with more is never in a ladder
+// Requires that the Intersection intn be a stone
+func (pos *Position) ReadLadder(intn Intersection) Ladder {
+	if pos.board.colorAt(intn) == Empty {
+		panic("Tried to read ladder on empty intersection")
+	}
+	black := pos.board.isBlackStone(intn)
+	chain := pos.board.chainMask(intn)
+	liberties := pos.board.libertiesMask(&chain)
+	// The ko only binds the player to move
+	ko, _ := pos.KoPoint()
+	var works bool
+	var moves []Intersection
+	var end Board
+	switch liberties.count() {
+	case 1:
+		if pos.blacksTurn != black {
+			ko = PASS
+		}
+		works, moves, end = pos.board.ladderEscapeFails(intn, !black, ko, LADDER_DEPTH)
+	case 2:
+		if pos.blacksTurn == black {
+			ko = PASS
+		}
+		works, moves, end = pos.board.ladderCaptures(intn, !black, ko, LADDER_DEPTH)
+	default:
+		return Ladder{}
+	}
+	ladder := Ladder{Works: works, Moves: moves}
+	if !works {
+		breakers := pos.board.joinedStones(intn, &end)
+		ladder.Breakers = breakers.intersections()
+	}
+	return ladder
+}
 
+// Returns the stones on the board that the chain on the intersection has
+// joined by the end of a line
+func (board *Board) joinedStones(intn Intersection, end *Board) bitboard {
+	if end.isEmpty(intn) {
+		return bitboard{}
+	}
+	own := bitboard(board.white)
+	if board.isBlackStone(intn) {
+		own = board.black
+	}
+	chain := board.chainMask(intn)
+	joined := end.chainMask(intn)
+	joined = joined.and(own)
+	for i := range joined {
+		joined[i] &^= chain[i]
+	}
+	return joined
+}
+
+// Asks if the attacker, to move, captures the chain on the intersection,
+// which has two liberties, by giving atari
+// Returns the line read, and the board at its end
+func (board *Board) ladderCaptures(intn Intersection, blackAttacks bool, ko Intersection, depth int) (bool, []Intersection, Board) {
+	chain := board.chainMask(intn)
+	liberties := board.libertiesMask(&chain)
+	if depth == 0 {
+		return false, nil, *board
+	}
+	var line []Intersection
+	end := *board
+	lineJoins := false
+	for _, move := range liberties.intersections() {
+		next, nextKo, ok := board.readMove(move, blackAttacks, ko)
+		if !ok {
+			continue
+		}
+		nextChain := next.chainMask(intn)
+		if nextLiberties := next.libertiesMask(&nextChain); nextLiberties.count() != 1 {
+			continue
+		}
+		fails, rest, restEnd := next.ladderEscapeFails(intn, blackAttacks, nextKo, depth-1)
+		if fails {
+			return true, append([]Intersection{move}, rest...), restEnd
+		}
+		// Keep the escape that shows why the ladder fails: one that runs
+		// into other stones of the chain's color, else the longest
+		joined := board.joinedStones(intn, &restEnd)
+		joins := !joined.isZero()
+		if (joins && !lineJoins) || (joins == lineJoins && len(rest)+1 > len(line)) {
+			line, end, lineJoins = append([]Intersection{move}, rest...), restEnd, joins
+		}
+	}
+	return false, line, end
+}
+
+// Asks if the chain on the intersection, in atari and to move, is
+// captured in a ladder whatever it does
+// It may extend on its liberty, or capture a chain next to it in atari
+// Returns the line read, and the board at its end
+func (board *Board) ladderEscapeFails(intn Intersection, blackAttacks bool, ko Intersection, depth int) (bool, []Intersection, Board) {
+	chain := board.chainMask(intn)
+	liberties := board.libertiesMask(&chain)
+	if depth == 0 {
+		return false, nil, *board
+	}
+	moves := liberties.intersections()
+	moves = append(moves, board.capturesNextTo(&chain, blackAttacks)...)
+	var line []Intersection
+	end := *board
+	for _, move := range moves {
+		next, nextKo, ok := board.readMove(move, !blackAttacks, ko)
+		if !ok {
+			continue
+		}
+		nextChain := next.chainMask(intn)
+		nextLiberties := next.libertiesMask(&nextChain)
+		switch nextLiberties.count() {
+		case 1:
+			// Still in atari, so taken next move, unless that retakes a ko
+			capture, _, captured := next.readMove(nextLiberties.first(), blackAttacks, nextKo)
+			if !captured {
+				return false, []Intersection{move}, next
+			}
+			if len(line) < 2 {
+				line, end = []Intersection{move, nextLiberties.first()}, capture
+			}
+		case 2:
+			captures, rest, restEnd := next.ladderCaptures(intn, blackAttacks, nextKo, depth-1)
+			if !captures {
+				return false, append([]Intersection{move}, rest...), restEnd
+			}
+			if len(rest)+1 > len(line) {
+				line, end = append([]Intersection{move}, rest...), restEnd
+			}
+		default:
+			return false, []Intersection{move}, next
+		}
+	}
+	if line == nil {
+		// No legal move at all, so the chain passes and the attacker takes
+		// the last liberty, free of any ko
+		capture, _, captured := board.readMove(liberties.first(), blackAttacks, PASS)
+		if !captured {
+			return false, nil, *board
+		}
+		return true, []Intersection{PASS, liberties.first()}, capture
+	}
+	return true, line, end
 }
-*/
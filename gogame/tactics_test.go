@@ -0,0 +1,176 @@
+package gogame
+
+import (
+	"strings"
+	"testing"
+)
+
+// A 19x19 board with a white stone on 3 3 that black can ladder
+// towards either the top left corner or the bottom right, and the given
+// stones added, X for black and O for white
+func ladderRows(stones map[Intersection]byte) []string {
+	rows := strings.Split(strings.Repeat("...................\n", 19), "\n")[:19]
+	rows[2] = "....X.............."
+	rows[3] = "..XO..............."
+	rows[4] = "...X..............."
+	for intn, stone := range stones {
+		row := []byte(rows[intn.x])
+		row[intn.y] = stone
+		rows[intn.x] = string(row)
+	}
+	return rows
+}
+
+// The ladder with black to move, which takes more moves than the status
+// reads ahead
+func longLadderPosition() Position {
+	return positionFromRows(ladderRows(nil), true, ChineseRules)
+}
+
+func TestReadLadder(t *testing.T) {
+	white := Intersection{3, 3}
+	tests := []struct {
+		name       string
+		stones     map[Intersection]byte
+		blacksTurn bool
+		works      bool
+		breaker    Intersection
+	}{
+		{"working", nil, true, true, PASS},
+		{"in atari", map[Intersection]byte{{3, 4}: 'X'}, false, true, PASS},
+		{"broken", map[Intersection]byte{{0, 2}: 'O', {10, 10}: 'O'}, true, false, Intersection{10, 10}},
+	}
+	for _, test := range tests {
+		pos := positionFromRows(ladderRows(test.stones), test.blacksTurn, ChineseRules)
+		ladder := pos.ReadLadder(white)
+		if ladder.Works != test.works {
+			t.Errorf("%s: ladder works %v, want %v", test.name, ladder.Works, test.works)
+			continue
+		}
+		// The line alternates from the player to move, so it can be played
+		for k, move := range ladder.Moves {
+			next, err := pos.Play(move)
+			if err != nil {
+				t.Fatalf("%s: move %d of %v: %v", test.name, k, ladder.Moves, err)
+			}
+			pos = next
+		}
+		if captured := pos.board.isEmpty(white); captured != test.works {
+			t.Errorf("%s: chain captured %v at the end of %v", test.name, captured, ladder.Moves)
+		}
+		if test.works && len(ladder.Breakers) != 0 {
+			t.Errorf("%s: working ladder broken by %v", test.name, ladder.Breakers)
+		}
+		if !test.works && (len(ladder.Breakers) != 1 || ladder.Breakers[0] != test.breaker) {
+			t.Errorf("%s: broken by %v, want %s", test.name, ladder.Breakers, test.breaker)
+		}
+	}
+}
+
+func TestStatusOfLongLadder(t *testing.T) {
+	pos := longLadderPosition()
+	white := Intersection{3, 3}
+	ladder := pos.ReadLadder(white)
+	if !ladder.Works || len(ladder.Moves) <= 2*STATUS_DEPTH {
+		t.Fatalf("ladder works %v in %d moves, want it to work beyond the status depth", ladder.Works, len(ladder.Moves))
+	}
+	if status := pos.Status(white); status == Alive {
+		t.Errorf("chain in a working ladder is %s", status)
+	}
+}
+
+// A white stone on the edge, taken by an atari from below but saved by
+// extending there first
+var edgeStoneRows = []string{
+	"...XO....",
+	".....X...",
+	".........",
+	".........",
+	".........",
+	".........",
+	".........",
+	".........",
+	".........",
+}
+
+// A black stone that has just taken a ko, and the same board with no
+// ko to bind white
+var koRows = []string{
+	".XO......",
+	"X.XO.....",
+	".XO......",
+	".........",
+	".........",
+	".........",
+	".........",
+	".........",
+	".........",
+}
+
+func TestReadFight(t *testing.T) {
+	beforeKo := positionFromRows([]string{
+		".XO......",
+		"XO.O.....",
+		".XO......",
+		".........",
+		".........",
+		".........",
+		".........",
+		".........",
+		".........",
+	}, true, ChineseRules)
+	ko, err := beforeKo.Play(Intersection{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	edge := Intersection{0, 4}
+	tests := []struct {
+		name    string
+		pos     Position
+		target  Intersection
+		attack  bool
+		depth   int
+		nodes   int
+		success bool
+		settled bool
+		move    Intersection
+	}{
+		{"captured", positionFromRows(edgeStoneRows, true, ChineseRules), edge, true, 20, 10000, true, true, Intersection{1, 4}},
+		{"escapes moving first", positionFromRows(edgeStoneRows, false, ChineseRules), edge, false, 20, 10000, true, true, Intersection{1, 4}},
+		{"out of depth", positionFromRows(edgeStoneRows, true, ChineseRules), edge, true, 2, 10000, false, false, PASS},
+		{"out of nodes", positionFromRows(edgeStoneRows, true, ChineseRules), edge, true, 20, 10, false, false, PASS},
+		{"ko bans the capture", ko, Intersection{1, 2}, true, 20, 10000, false, true, PASS},
+		{"no ko", positionFromRows(koRows, false, ChineseRules), Intersection{1, 2}, true, 20, 10000, true, true, Intersection{1, 1}},
+	}
+	for _, test := range tests {
+		var reading Reading
+		if test.attack {
+			reading = test.pos.ReadAttack(test.target, test.depth, test.nodes)
+		} else {
+			reading = test.pos.ReadDefence(test.target, test.depth, test.nodes)
+		}
+		if reading.Success != test.success || reading.Settled != test.settled {
+			t.Errorf("%s: success %v settled %v, want %v and %v", test.name, reading.Success, reading.Settled, test.success, test.settled)
+		}
+		// The move is only known to be the key one if it gets its way
+		if test.success && reading.Move != test.move {
+			t.Errorf("%s: read move %s, want %s", test.name, reading.Move, test.move)
+		}
+		if reading.Nodes > test.nodes {
+			t.Errorf("%s: searched %d positions, over the budget of %d", test.name, reading.Nodes, test.nodes)
+		}
+	}
+}
+
+func TestReaderKeepsTable(t *testing.T) {
+	pos := positionFromRows(edgeStoneRows, true, ChineseRules)
+	reader := newReader(&pos.board, Intersection{0, 4}, 10000)
+	first := reader.read(&pos, true, 20)
+	again := reader.read(&pos, true, 20)
+	if again.Success != first.Success || again.Move != first.Move {
+		t.Errorf("read %+v again as %+v", first, again)
+	}
+	if again.Nodes >= first.Nodes {
+		t.Errorf("read again in %d positions, no fewer than the first %d", again.Nodes, first.Nodes)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"sort"
 	"strconv"
 )
 
@@ -14,70 +15,74 @@ const NUM_FILES int = 10
 
 const PRINT bool = true
 
+// Returns the name of the ith data file, zero padded
+func datafileName(i int) string {
+	numberString := strconv.Itoa(i)
+	for len(numberString) < len(strconv.Itoa(NUM_FILES)) {
+		numberString = "0" + numberString
+	}
+	return "godata/datafile_" + numberString
+}
+
 // Creates clean files in godata subdirectory
-func MakeDatafiles() {
+func MakeDatafiles() error {
 
 	for i := 0; i < NUM_FILES; i++ {
-		// Get the zero padding right
-		numberString := strconv.Itoa(i)
-		for len(numberString) < len(strconv.Itoa(NUM_FILES)) {
-			numberString = "0" + numberString
-		}
-		filename := "godata/datafile_" + numberString
 		// Make the file, 0664 for read/write permission
-		err := ioutil.WriteFile(filename, []byte{}, 0644)
+		err := ioutil.WriteFile(datafileName(i), []byte{}, 0644)
 		if err != nil {
-			panic(err)
+			return err
 		}
 	}
-
+	return nil
 }
 
 // Read the ith file.
 // Takes as argument an int corresponding to one of the data files
 // Returns a slice of bytes being the data within that file
-func readDatafile(i int) []byte {
+func readDatafile(i int) ([]byte, error) {
 	if i < 0 || i >= NUM_FILES {
-		panic("Illegal argument: file number out of range.\n")
+		return nil, fmt.Errorf("datafile number %d out of range", i)
 	}
+	return ioutil.ReadFile(datafileName(i))
+}
 
-	numberString := strconv.Itoa(i)
-	for len(numberString) < len(strconv.Itoa(NUM_FILES)) {
-		numberString = "0" + numberString
-	}
-
-	filename := "godata/datafile_" + numberString
-
-	data, err := ioutil.ReadFile(filename)
+func printData(i int) error {
+	file, err := readDatafile(i)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	return data
-}
-
-func printData(i int) {
-	file := readDatafile(i)
 	for _, datum := range file {
 		fmt.Printf("%d\n", datum)
 	}
+	return nil
 }
 
 // Write data to the ith file
 // Takes as argument an int corresponding to one of the datafiles
 // Takes as argument a slice of bytes
 // Writes the slice of bytes into the file specified by the int
-func writeDatafile(i int, data []byte) {
-	// Get the zero padding right
-	numberString := strconv.Itoa(i)
-	for len(numberString) < len(strconv.Itoa(NUM_FILES)) {
-		numberString = "0" + numberString
+func writeDatafile(i int, data []byte) error {
+	if i < 0 || i >= NUM_FILES {
+		return fmt.Errorf("datafile number %d out of range", i)
+	}
+	return ioutil.WriteFile(datafileName(i), data, 0644)
+}
+
+// Swaps the data in the ith and jth files
+func swapDatafiles(i, j int) error {
+	iData, err := readDatafile(i)
+	if err != nil {
+		return err
 	}
-	filename := "godata/datafile_" + numberString
-	// Write to the file
-	err := ioutil.WriteFile(filename, data, 0644)
+	jData, err := readDatafile(j)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	if err := writeDatafile(i, jData); err != nil {
+		return err
 	}
+	return writeDatafile(j, iData)
 }
 
 // Makes a player-type function using a the data from the ith file
@@ -85,8 +90,13 @@ func writeDatafile(i int, data []byte) {
 // The analysis is based on the board position, and on turn.
 // The the analysis returns a int64 for each legal move.
 // The Player will return the largest rating
-func PlayerMaker(i int) func(Position) Intersection {
-	return DataPlayerMaker(readDatafile(i))
+// The player is named after the file
+func PlayerMaker(i int) (Player, error) {
+	data, err := readDatafile(i)
+	if err != nil {
+		return nil, err
+	}
+	return FuncPlayer(fmt.Sprintf("datafile %d", i), DataPlayerMaker(data)), nil
 }
 
 // Helper function for Playermaker
@@ -96,9 +106,10 @@ func DataPlayerMaker(data []byte) func(Position) Intersection {
 
 		var maxAnalysis int64 = -1
 		var bestIntn Intersection = PASS
+		size := pos.board.size
 		// Loop through all intersections
-		for i := 0; uint8(i) < SIZE; i++ {
-			for j := 0; uint8(j) < SIZE; j++ {
+		for i := 0; uint8(i) < size; i++ {
+			for j := 0; uint8(j) < size; j++ {
 				var intn Intersection = Intersection{uint8(i), uint8(j)}
 				if pos.worseThanPass(intn) {
 					continue
@@ -266,7 +277,7 @@ func analyzer(data []byte, board Board, intn Intersection) int64 {
 				if decided {
 					black := tplt.isBlack(x, y)
 					white := tplt.isWhite(x, y)
-					if xCoord >= SIZE || yCoord >= SIZE {
+					if !board.onBoard(scanIntn) {
 						satisfied = satisfied && black && white
 					} else if board.isBlackStone(scanIntn) {
 						satisfied = satisfied && black && !white
@@ -294,38 +305,92 @@ func PrintAnalyzer(data []byte) {
 	}
 }
 
+// Counts the games of a tournament lost to panics, to show how many
+// genomes are malformed
+type panicStats struct {
+	games  int
+	panics int
+	// Panics of each player, by name
+	players map[string]int
+	// Panics with each message
+	messages map[string]int
+}
+
+func newPanicStats() *panicStats {
+	return &panicStats{players: make(map[string]int), messages: make(map[string]int)}
+}
+
+// Records a game that has been played
+func (stats *panicStats) record(game *Game) {
+	stats.games++
+	if game.Result == nil {
+		return
+	}
+	panicErr, ok := game.Result.Err.(*PanicError)
+	if !ok {
+		return
+	}
+	stats.panics++
+	name := game.Config.BlackName
+	if game.Result.Winner == Black {
+		name = game.Config.WhiteName
+	}
+	stats.players[name]++
+	stats.messages[fmt.Sprint(panicErr.Value)]++
+}
+
+func (stats *panicStats) PrintOut() {
+	fmt.Printf("Panics: %d of %d games, by %d players\n", stats.panics, stats.games, len(stats.players))
+	messages := []string{}
+	for message := range stats.messages {
+		messages = append(messages, message)
+	}
+	sort.Strings(messages)
+	for _, message := range messages {
+		fmt.Printf("  %d x %s\n", stats.messages[message], message)
+	}
+}
+
 // Runs a round rbin style tournament
 // Each player is created from the respective data file
 // Each player plays each other player, once as white, once as black
 // The total scores make up the scoreboard
-func RoundRobin() {
+// Options are passed on to every game played
+func RoundRobin(options ...GameOption) error {
 	// Initialize the array of players and the array of scores
-	var players [NUM_FILES]func(Position) Intersection
+	var players [NUM_FILES]Player
 	var scoreBoard [NUM_FILES]uint64
 	// Fill the array of players with the players
 	for i := 0; i < NUM_FILES; i++ {
-		players[i] = PlayerMaker(i)
+		player, err := PlayerMaker(i)
+		if err != nil {
+			return err
+		}
+		players[i] = player
 	}
 	// Loop through each pair of players, playing a game and printing out
+	stats := newPanicStats()
 	for i := 0; i < NUM_FILES; i++ {
 		for j := 0; j < NUM_FILES; j++ {
 			fmt.Printf("Round Robin Challenge: %d, %d\n", i, j)
-			var challengeGame Game = MakeGame(players[i], players[j])
-			iScore, jScore := challengeGame.PlayGame()
-			scoreBoard[i] += uint64(iScore)
-			scoreBoard[j] += uint64(jScore)
+			var challengeGame Game = MakeGame(players[i], players[j], options...)
+			result := challengeGame.PlayGame()
+			result.PrintOut()
+			stats.record(&challengeGame)
+			scoreBoard[i] += uint64(result.BlackScore)
+			scoreBoard[j] += uint64(result.WhiteScore)
 		}
 	}
+	stats.PrintOut()
 
 	// Sort by score
 	for i := 0; i < NUM_FILES; i++ {
 		for j := 0; j < i; j++ {
 			if scoreBoard[i] > scoreBoard[j] {
 				scoreBoard[i], scoreBoard[j] = scoreBoard[j], scoreBoard[i]
-				iData := readDatafile(i)
-				jData := readDatafile(j)
-				writeDatafile(i, jData)
-				writeDatafile(j, iData)
+				if err := swapDatafiles(i, j); err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -334,26 +399,32 @@ func RoundRobin() {
 		fmt.Printf("Scoreboard: %d has %d points \n", i, scoreBoard[i])
 	}
 	// Mutate the last file
-	crucibleOfFire(NUM_FILES - 1)
+	return crucibleOfFire(NUM_FILES-1, options...)
 
 }
 
-func crucibleOfFire(i int) {
+func crucibleOfFire(i int, options ...GameOption) error {
 	fmt.Printf("Begin Crucible\n")
-	for {
+	stats := newPanicStats()
+	for tried := 0; ; tried++ {
 
 		newData := []byte{}
 		for len(newData) < 512 {
 			newData = append(newData, byte(rand.Intn(256)))
 		}
-		writeDatafile(i, newData)
-		cruciblePlayer := PlayerMaker(i)
-		gameToShow := MakeGame(cruciblePlayer, CapturePlayer)
+		if err := writeDatafile(i, newData); err != nil {
+			return err
+		}
+		cruciblePlayer := FuncPlayer(fmt.Sprintf("crucible %d", tried), DataPlayerMaker(newData))
+		gameToShow := MakeGame(cruciblePlayer, FuncPlayer("capture", CapturePlayer), options...)
 		fmt.Printf("Play Crucible\n")
-		i, j := gameToShow.PlayGame()
-		if i > j+10 {
+		result := gameToShow.PlayGame()
+		result.PrintOut()
+		stats.record(&gameToShow)
+		if result.BlackScore > result.WhiteScore+10 {
 			fmt.Printf("End Crucible\n")
-			return
+			stats.PrintOut()
+			return nil
 		}
 	}
 }
@@ -363,55 +434,81 @@ func crucibleOfFire(i int) {
 // Creates two players from files i and j
 // plays them against each other
 // The winner takes the i ranking (i should be better ranked than j)
-func challenge(i, j int) {
+// Panics in the games are recorded in stats
+func challenge(i, j int, stats *panicStats, options ...GameOption) error {
 	fmt.Printf("Challenge: %d, %d\n", i, j)
 	if i >= j {
-		panic("Better ranking challenging worse")
+		return fmt.Errorf("better ranking %d challenging worse %d", i, j)
+	}
+	iPlayer, err := PlayerMaker(i)
+	if err != nil {
+		return err
+	}
+	jPlayer, err := PlayerMaker(j)
+	if err != nil {
+		return err
 	}
 
-	var challengeGame1 Game = MakeGame(PlayerMaker(i), PlayerMaker(j))
-	iScore1, jScore1 := challengeGame1.PlayGame()
+	var challengeGame1 Game = MakeGame(iPlayer, jPlayer, options...)
+	result1 := challengeGame1.PlayGame()
+	result1.PrintOut()
+	stats.record(&challengeGame1)
 
-	var challengeGame2 Game = MakeGame(PlayerMaker(j), PlayerMaker(i))
-	jScore2, iScore2 := challengeGame2.PlayGame()
+	var challengeGame2 Game = MakeGame(jPlayer, iPlayer, options...)
+	result2 := challengeGame2.PlayGame()
+	result2.PrintOut()
+	stats.record(&challengeGame2)
 
 	// See if j beat i
-	if jScore1+jScore2 >= iScore1+iScore2 {
+	iScore := result1.BlackScore + result2.WhiteScore
+	jScore := result1.WhiteScore + result2.BlackScore
+	if jScore >= iScore {
 		fmt.Printf("%d beat %d: switched\n", j, i)
-		iData := readDatafile(i)
-		jData := readDatafile(j)
-		writeDatafile(i, jData)
-		writeDatafile(j, iData)
+		return swapDatafiles(i, j)
 	}
+	return nil
 }
 
 // Another tourney style
-func QuadEvolve() {
+func QuadEvolve(options ...GameOption) error {
 	// Choose four random files
 	var players [4]int
+	var data [4][]byte
 	for i := 0; i < 4; i++ {
 		players[i] = rand.Intn(NUM_FILES)
-		fmt.Printf("Chose %d with length %d\n", players[i], len(readDatafile(i)))
+		var err error
+		data[i], err = readDatafile(players[i])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Chose %d with length %d\n", players[i], len(data[i]))
 	}
 	var scoreBoard [4]uint64
+	stats := newPanicStats()
 	for i := 0; i < 4; i++ {
 		for j := 0; j < 4; j++ {
-			var challengeGame Game = MakeGame(PlayerMaker(players[i]), PlayerMaker(players[j]))
-			iScore, jScore := 0, 0
-			iScore, jScore = challengeGame.PlayGame()
+			iPlayer := FuncPlayer(fmt.Sprintf("datafile %d", players[i]), DataPlayerMaker(data[i]))
+			jPlayer := FuncPlayer(fmt.Sprintf("datafile %d", players[j]), DataPlayerMaker(data[j]))
+			var challengeGame Game = MakeGame(iPlayer, jPlayer, options...)
+			result := challengeGame.PlayGame()
 			if PRINT {
 				challengeGame.PrintGame()
+			} else {
+				result.PrintOut()
 			}
-			scoreBoard[i] += uint64(iScore)
-			scoreBoard[j] += uint64(jScore)
+			stats.record(&challengeGame)
+			scoreBoard[i] += uint64(result.BlackScore)
+			scoreBoard[j] += uint64(result.WhiteScore)
 		}
 	}
+	stats.PrintOut()
 	//Sort
 	for i := 0; i < 4; i++ {
 		for j := 0; j < i; j++ {
 			if scoreBoard[i] > scoreBoard[j] {
 				scoreBoard[i], scoreBoard[j] = scoreBoard[j], scoreBoard[i]
 				players[i], players[j] = players[j], players[i]
+				data[i], data[j] = data[j], data[i]
 			}
 		}
 	}
@@ -420,12 +517,12 @@ func QuadEvolve() {
 	}
 	// winners child replace the losers
 	// Get a random sequence from winner1 - ensure at least 1 byte
-	winner1 := append(readDatafile(players[0]), byte(rand.Intn(256)))
+	winner1 := append(data[0], byte(rand.Intn(256)))
 	gene1end := rand.Intn(len(winner1)) + 1
 	gene1start := rand.Intn(gene1end)
 	gene1 := winner1[gene1start:gene1end]
 	// Get a random sequence from winner2 - ensure at least 1 byte
-	winner2 := append(readDatafile(players[1]), byte(rand.Intn(256)))
+	winner2 := append(data[1], byte(rand.Intn(256)))
 	gene2end := rand.Intn(len(winner2)) + 1
 	gene2start := rand.Intn(gene2end)
 	gene2 := winner2[gene2start:gene2end]
@@ -438,22 +535,29 @@ func QuadEvolve() {
 	}
 	fmt.Printf("Made a child:\n")
 	PrintAnalyzer(child1)
-	writeDatafile(players[2], child1)
-	writeDatafile(players[3], child2)
+	if err := writeDatafile(players[2], child1); err != nil {
+		return err
+	}
+	return writeDatafile(players[3], child2)
 }
 
 // Runs a tournament
-func Gauntlet() {
+func Gauntlet(options ...GameOption) error {
 	fmt.Println("Running tournament")
+	stats := newPanicStats()
 	for pres := 0; pres < FILES_PRESERVED; pres++ {
 		for i := pres + 1; i < NUM_FILES; i++ {
-			challenge(pres, i)
+			if err := challenge(pres, i, stats, options...); err != nil {
+				return err
+			}
 		}
 	}
+	stats.PrintOut()
+	return nil
 }
 
 // Tests a gene by seeing
-func GeneTester(gene []byte) float64 {
+func GeneTester(gene []byte, options ...GameOption) (float64, error) {
 	fmt.Println("Testing gene")
 	// Randomly seed the unpreserved files
 	for i := FILES_PRESERVED; i < NUM_FILES; i++ {
@@ -461,74 +565,103 @@ func GeneTester(gene []byte) float64 {
 		for len(randomData) < len(gene) {
 			randomData = append(randomData, byte(rand.Intn(256)))
 		}
-		writeDatafile(i, randomData)
+		if err := writeDatafile(i, randomData); err != nil {
+			return 0, err
+		}
+	}
+	var data [NUM_FILES][]byte
+	for i := 0; i < NUM_FILES; i++ {
+		var err error
+		data[i], err = readDatafile(i)
+		if err != nil {
+			return 0, err
+		}
 	}
 	// Now test the gene
 	var geneScore uint64
 	var otherScore uint64
+	stats := newPanicStats()
 	// Use a round robin
 	for i := 0; i < NUM_FILES; i++ {
 		for j := 0; j < NUM_FILES; j++ {
-			black1 := DataPlayerMaker(append(readDatafile(i), gene...))
-			white1 := PlayerMaker(j)
-			var challengeGame1 Game = MakeGame(black1, white1)
-			iScore, jScore := challengeGame1.PlayGame()
+			black1 := FuncPlayer("gene", DataPlayerMaker(append(data[i], gene...)))
+			white1 := FuncPlayer(fmt.Sprintf("datafile %d", j), DataPlayerMaker(data[j]))
+			var challengeGame1 Game = MakeGame(black1, white1, options...)
+			result := challengeGame1.PlayGame()
 			challengeGame1.PrintGame()
-			geneScore += uint64(iScore)
-			otherScore += uint64(jScore)
+			stats.record(&challengeGame1)
+			geneScore += uint64(result.BlackScore)
+			otherScore += uint64(result.WhiteScore)
 			// Switch gene side
-			black2 := PlayerMaker(i)
-			white2 := DataPlayerMaker(append(readDatafile(j), gene...))
-			var challengeGame2 Game = MakeGame(black2, white2)
-			iScore, jScore = challengeGame2.PlayGame()
-			otherScore += uint64(iScore)
-			geneScore += uint64(jScore)
+			black2 := FuncPlayer(fmt.Sprintf("datafile %d", i), DataPlayerMaker(data[i]))
+			white2 := FuncPlayer("gene", DataPlayerMaker(append(data[j], gene...)))
+			var challengeGame2 Game = MakeGame(black2, white2, options...)
+			result = challengeGame2.PlayGame()
+			result.PrintOut()
+			stats.record(&challengeGame2)
+			otherScore += uint64(result.BlackScore)
+			geneScore += uint64(result.WhiteScore)
 		}
 	}
+	stats.PrintOut()
 	// Get the new genes improvement coefficient
 	improvement := float64(geneScore) / float64(geneScore+otherScore)
 	fmt.Printf("Scored %f\n", improvement)
-	return improvement
+	return improvement, nil
 }
 
 // Removes bytes from a gene until it starts corrupting the gene
-func GeneImprover(gene []byte) []byte {
+func GeneImprover(gene []byte, options ...GameOption) ([]byte, error) {
 	// Try to improve n times
-	currentScore := GeneTester(gene)
+	currentScore, err := GeneTester(gene, options...)
+	if err != nil {
+		return nil, err
+	}
 	for i := 0; i < 5; i++ {
 		toMutate := rand.Intn(len(gene))
 		mutatedGene := append(gene[:toMutate], gene[toMutate+1:]...)
-		if GeneTester(mutatedGene) > currentScore {
+		mutatedScore, err := GeneTester(mutatedGene, options...)
+		if err != nil {
+			return nil, err
+		}
+		if mutatedScore > currentScore {
 			fmt.Println("Gene improved")
-			return GeneImprover(mutatedGene)
+			return GeneImprover(mutatedGene, options...)
 		}
 	}
-	return gene
+	return gene, nil
 }
 
-func BeatCapturePlayer() []byte {
-	for {
+func BeatCapturePlayer(options ...GameOption) []byte {
+	stats := newPanicStats()
+	for tried := 0; ; tried++ {
 		gene := []byte{}
 		for len(gene) < 20 {
 			gene = append(gene, byte(rand.Intn(256)))
 		}
 
-		black := CapturePlayer
-		white := DataPlayerMaker(gene)
-		var challengeGame1 Game = MakeGame(black, white)
-		iScore1, jScore1 := challengeGame1.PlayGame()
-		if iScore1 > jScore1 {
+		black := FuncPlayer("capture", CapturePlayer)
+		white := FuncPlayer(fmt.Sprintf("gene %d", tried), DataPlayerMaker(gene))
+		var challengeGame1 Game = MakeGame(black, white, options...)
+		result1 := challengeGame1.PlayGame()
+		stats.record(&challengeGame1)
+		if result1.Winner == Black {
 			continue
 		}
-		var challengeGame2 Game = MakeGame(black, white)
-		iScore2, jScore2 := challengeGame2.PlayGame()
-		var challengeGame3 Game = MakeGame(black, white)
-		iScore3, jScore3 := challengeGame3.PlayGame()
-		if iScore1+iScore2+iScore3 < jScore1+jScore2+jScore3 {
+		var challengeGame2 Game = MakeGame(black, white, options...)
+		result2 := challengeGame2.PlayGame()
+		stats.record(&challengeGame2)
+		var challengeGame3 Game = MakeGame(black, white, options...)
+		result3 := challengeGame3.PlayGame()
+		stats.record(&challengeGame3)
+		blackScore := result1.BlackScore + result2.BlackScore + result3.BlackScore
+		whiteScore := result1.WhiteScore + result2.WhiteScore + result3.WhiteScore
+		if blackScore < whiteScore {
 			PrintAnalyzer(gene)
-			var challengeGame Game = MakeGame(black, white)
+			var challengeGame Game = MakeGame(black, white, options...)
 			challengeGame.PlayGame()
 			challengeGame.PrintGame()
+			stats.PrintOut()
 			return gene
 		}
 	}
@@ -0,0 +1,190 @@
+package gogame
+
+// A tree of the variations of a game, for analysis and review
+// Each line of play from the root is a game, the first child of every
+// node giving the main line
+type GameTree struct {
+	// The settings of the game, which every line shares
+	Config GameConfig
+	// The first board of the game, with any handicap stones
+	Root *TreeNode
+	// Whether white makes the first move
+	whiteFirst bool
+}
+
+// A node of a game tree: a move, and the board after it
+type TreeNode struct {
+	// The move that led here, empty at the root
+	Move  Move
+	Board Board
+	// Remarks on the position, as written in SGF
+	Comment string
+	// How the game ended, for the last node of a line that has ended
+	Result *GameResult
+	// The node before, nil at the root
+	Parent *TreeNode
+	// Moves played from here, the main line first
+	Children []*TreeNode
+}
+
+// Makes a tree with the moves of a game as its main line
+// Moves taken back that can be redone are kept at the end of the line
+func NewGameTree(game *Game) *GameTree {
+	tree := &GameTree{
+		Config:     game.Config,
+		Root:       &TreeNode{Board: game.BoardList[0]},
+		whiteFirst: game.whiteFirst,
+	}
+	node := tree.Root
+	for k, move := range game.Moves {
+		node = node.addChild(move, game.BoardList[k+1])
+	}
+	for k := len(game.undone) - 1; k >= 0; k-- {
+		board := node.Board
+		playMove(&board, game.undone[k].Color, game.undone[k].Point)
+		node = node.addChild(game.undone[k], board)
+	}
+	node.Result = game.Result
+	return tree
+}
+
+// Adds a node after this one, as its last variation
+func (node *TreeNode) addChild(move Move, board Board) *TreeNode {
+	child := &TreeNode{Move: move, Board: board, Parent: node}
+	node.Children = append(node.Children, child)
+	return child
+}
+
+// Returns the nodes from the root to this node, in order
+func (node *TreeNode) Line() []*TreeNode {
+	depth := 0
+	for parent := node; parent != nil; parent = parent.Parent {
+		depth++
+	}
+	line := make([]*TreeNode, depth)
+	for parent := node; parent != nil; parent = parent.Parent {
+		depth--
+		line[depth] = parent
+	}
+	return line
+}
+
+// Asks if the node is on the main line
+func (node *TreeNode) IsMainLine() bool {
+	for ; node.Parent != nil; node = node.Parent {
+		if node.Parent.Children[0] != node {
+			return false
+		}
+	}
+	return true
+}
+
+// Makes the line through the node the main line, moving it ahead of the
+// other variations at every branch before it
+func (node *TreeNode) Promote() {
+	for ; node.Parent != nil; node = node.Parent {
+		siblings := node.Parent.Children
+		for k := range siblings {
+			if siblings[k] == node {
+				copy(siblings[1:k+1], siblings[:k])
+				siblings[0] = node
+				break
+			}
+		}
+	}
+}
+
+// Returns the color to move after the node
+func (tree *GameTree) toMove(node *TreeNode) Color {
+	if node.Parent == nil {
+		return colorOf(!tree.whiteFirst)
+	}
+	return node.Move.Color.Opponent()
+}
+
+// Adds the move of the player to move after the node as a variation,
+// or finds the variation with that move if there is one
+// The move must be legal under the rules of the game, given the line
+// that led to the node
+func (tree *GameTree) AddVariation(node *TreeNode, move Intersection) (*TreeNode, error) {
+	for _, child := range node.Children {
+		if child.Move.Point == move {
+			return child, nil
+		}
+	}
+	game := tree.Game(node)
+	pos := game.makeCurrentPosition()
+	if move == RESIGN || move == UNDO || !pos.isLegal(move) {
+		return nil, &IllegalMoveError{pos.blacksTurn, move}
+	}
+	if err := game.addMove(move); err != nil {
+		return nil, err
+	}
+	return node.addChild(game.Moves[len(game.Moves)-1], game.BoardList[len(game.BoardList)-1]), nil
+}
+
+// Returns the nodes of the main line, from the root
+func (tree *GameTree) MainLine() []*TreeNode {
+	node := tree.Root
+	for len(node.Children) > 0 {
+		node = node.Children[0]
+	}
+	return node.Line()
+}
+
+// Returns every line of play, from the root to a node with no moves
+// after it, the main line first
+func (tree *GameTree) Lines() [][]*TreeNode {
+	lines := [][]*TreeNode{}
+	stack := []*TreeNode{tree.Root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if len(node.Children) == 0 {
+			lines = append(lines, node.Line())
+			continue
+		}
+		// Pushed last first, so the first variation is taken first
+		for k := len(node.Children) - 1; k >= 0; k-- {
+			stack = append(stack, node.Children[k])
+		}
+	}
+	return lines
+}
+
+// Makes the game of the line from the root to the node
+// The main line on from the node can be played with Redo, and the game
+// has the result of the end of that line
+// The game has no players, so cannot be played on
+func (tree *GameTree) Game(node *TreeNode) Game {
+	game := Game{Config: tree.Config, whiteFirst: tree.whiteFirst}
+	game.seen = make(map[uint64]int)
+	line := node.Line()
+	game.BoardList = make([]Board, 0, len(line))
+	game.Moves = make([]Move, 0, len(line)-1)
+	game.BoardList = append(game.BoardList, tree.Root.Board)
+	game.recordPosition(&game.BoardList[0], game.blacksTurnAt(0))
+	for _, next := range line[1:] {
+		game.BoardList = append(game.BoardList, next.Board)
+		game.Moves = append(game.Moves, next.Move)
+		game.recordPosition(&next.Board, next.Move.Color == White)
+	}
+	// Redo plays the last move taken back first, so the main line on
+	// from the node is kept backwards
+	end := node
+	for ; len(end.Children) > 0; end = end.Children[0] {
+		game.undone = append(game.undone, end.Children[0].Move)
+	}
+	for i, j := 0, len(game.undone)-1; i < j; i, j = i+1, j-1 {
+		game.undone[i], game.undone[j] = game.undone[j], game.undone[i]
+	}
+	game.Result = end.Result
+	game.resetClocks()
+	return game
+}
+
+// Returns the position after the node, for a player to move from
+func (tree *GameTree) Position(node *TreeNode) Position {
+	game := tree.Game(node)
+	return game.makeCurrentPosition()
+}
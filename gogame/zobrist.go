@@ -0,0 +1,92 @@
+package gogame
+
+import (
+	"math/rand"
+)
+
+// Random keys for Zobrist hashing of boards
+// A board's hash is the xor of the keys of every stone on it
+var zobristBlack [MAX_SIZE][MAX_SIZE]uint64
+var zobristWhite [MAX_SIZE][MAX_SIZE]uint64
+
+// Key xored into the hash when white is to move (situational superko)
+var zobristWhiteToMove uint64
+
+func init() {
+	// Use a fixed seed, so hashes are the same from run to run
+	keys := rand.New(rand.NewSource(0x5eed))
+	for i := uint8(0); i < MAX_SIZE; i++ {
+		for j := uint8(0); j < MAX_SIZE; j++ {
+			zobristBlack[i][j] = keys.Uint64()
+			zobristWhite[i][j] = keys.Uint64()
+		}
+	}
+	zobristWhiteToMove = keys.Uint64()
+}
+
+// Rule for which repeated positions are forbidden
+type KoRule uint8
+
+const (
+	// A move may not recreate any earlier board
+	PositionalSuperko KoRule = iota
+	// A move may not recreate an earlier board with the same player to move
+	SituationalSuperko
+	// A move may not recreate the board from before the opponent's last move
+	SimpleKo
+)
+
+// Option to change the ko rule of the ruleset
+func Ko(rule KoRule) GameOption {
+	return func(config *GameConfig) {
+		config.Rules.Ko = rule
+	}
+}
+
+// Returns the Zobrist hash of the stones on the board
+func (board *Board) Hash() uint64 {
+	return board.hash
+}
+
+// Returns the key under which a board is remembered for the ko rule
+// blacksTurn says who is to move on that board
+func positionKey(board *Board, blacksTurn bool, ko KoRule) uint64 {
+	if ko == SituationalSuperko && !blacksTurn {
+		return board.hash ^ zobristWhiteToMove
+	}
+	return board.hash
+}
+
+// Returns the key of a board under the ko rule of the game
+func (game *Game) positionKey(board *Board, blacksTurn bool) uint64 {
+	return positionKey(board, blacksTurn, game.Config.Rules.Ko)
+}
+
+// Remembers a board that has occured in the game
+func (game *Game) recordPosition(board *Board, blacksTurn bool) {
+	game.seen[game.positionKey(board, blacksTurn)]++
+}
+
+// Forgets a board once, as its move is taken back
+func (game *Game) forgetPosition(board *Board, blacksTurn bool) {
+	key := game.positionKey(board, blacksTurn)
+	game.seen[key]--
+	if game.seen[key] <= 0 {
+		delete(game.seen, key)
+	}
+}
+
+// Asks if a board has occured before in the game
+func (game *Game) seenPosition(board *Board, blacksTurn bool) bool {
+	return game.seen[game.positionKey(board, blacksTurn)] > 0
+}
+
+// Asks if the ko rule forbids playing to reach the given board
+// blacksTurn says who is to move after the play
+func (game *Game) breaksKo(board *Board, blacksTurn bool) bool {
+	if game.Config.Rules.Ko == SimpleKo {
+		move := len(game.BoardList)
+		return move >= 2 && *board == game.BoardList[move-2]
+	}
+	return game.seenPosition(board, blacksTurn)
+}
@@ -0,0 +1,128 @@
+package gogame
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Three kos, the top and bottom held by white and the middle by black,
+// with black to move
+// Black takes a ko by playing on its fourth column, white on its third
+var tripleKoRows = []string{
+	"...XO....",
+	"..XO.O...",
+	"...XO....",
+	"...XO....",
+	"..X.XO...",
+	"...XO....",
+	"...XO....",
+	"..XO.O...",
+	"...XO....",
+}
+
+// Makes a game under the rules that starts from the board of the rows,
+// black to move
+func gameFromRows(rows []string, rules Ruleset) Game {
+	player := FuncPlayer("pass", func(pos Position) Intersection { return PASS })
+	game := MakeGame(player, player, BoardSize(uint8(len(rows))), Rules(rules))
+	game.BoardList[0] = positionFromRows(rows, true, rules).board
+	game.seen = make(map[uint64]int)
+	game.recordPosition(&game.BoardList[0], true)
+	return game
+}
+
+// Plays the moves, which must be legal, then asks if the last move is
+func legalAfter(t *testing.T, game *Game, moves []Intersection, last Intersection) bool {
+	for _, move := range moves {
+		pos := game.makeCurrentPosition()
+		if !pos.isLegal(move) {
+			t.Fatalf("%s under %s rules: move %s illegal", game.toMove(), game.Config.Rules.Name, move)
+		}
+		if err := game.appendMove(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pos := game.makeCurrentPosition()
+	return pos.isLegal(last)
+}
+
+// Returns the Zobrist hash of the stones on a board, worked out afresh
+func referenceHash(board *Board) uint64 {
+	var hash uint64
+	for i := uint8(0); i < board.size; i++ {
+		for j := uint8(0); j < board.size; j++ {
+			intn := Intersection{i, j}
+			if board.isBlackStone(intn) {
+				hash ^= zobristBlack[i][j]
+			} else if board.isWhiteStone(intn) {
+				hash ^= zobristWhite[i][j]
+			}
+		}
+	}
+	return hash
+}
+
+func TestTripleKo(t *testing.T) {
+	// Each player takes a ko in turn, until white's last capture would
+	// bring back the first board with black to move
+	cycle := []Intersection{{1, 4}, {4, 3}, {7, 4}, {1, 3}, {4, 4}}
+	last := Intersection{7, 3}
+	for _, test := range []struct {
+		rules Ruleset
+		legal bool
+	}{
+		{ChineseRules, false},
+		{AGARules, false},
+		{JapaneseRules, true},
+	} {
+		game := gameFromRows(tripleKoRows, test.rules)
+		if legal := legalAfter(t, &game, cycle, last); legal != test.legal {
+			t.Errorf("repeating the triple ko legal %v under %s rules, want %v", legal, test.rules.Name, test.legal)
+		}
+	}
+}
+
+func TestSituationalSuperko(t *testing.T) {
+	// A pass in the cycle brings back the first board with white to move
+	// instead
+	moves := []Intersection{{1, 4}, {4, 3}, PASS, {1, 3}}
+	last := Intersection{4, 4}
+	for _, test := range []struct {
+		rules Ruleset
+		legal bool
+	}{
+		{ChineseRules, false},
+		{TrompTaylorRules, false},
+		{AGARules, true},
+		{NewZealandRules, true},
+		{JapaneseRules, true},
+	} {
+		game := gameFromRows(tripleKoRows, test.rules)
+		if legal := legalAfter(t, &game, moves, last); legal != test.legal {
+			t.Errorf("first board with the other player to move legal %v under %s rules, want %v", legal, test.rules.Name, test.legal)
+		}
+	}
+}
+
+func TestHashAfterTakeBack(t *testing.T) {
+	game := gameFromRows(tripleKoRows, AGARules)
+	seen := make(map[uint64]int)
+	for key, count := range game.seen {
+		seen[key] = count
+	}
+	moves := []Intersection{{1, 4}, {4, 3}, PASS, {0, 0}, {7, 4}}
+	legalAfter(t, &game, moves, PASS)
+	for len(game.Moves) > 0 {
+		board := &game.BoardList[len(game.BoardList)-1]
+		if board.Hash() != referenceHash(board) {
+			t.Fatalf("hash %x after %d moves, want %x", board.Hash(), len(game.Moves), referenceHash(board))
+		}
+		game.takeBack()
+	}
+	if board := &game.BoardList[0]; board.Hash() != referenceHash(board) {
+		t.Errorf("hash %x after taking back every move, want %x", board.Hash(), referenceHash(board))
+	}
+	if !reflect.DeepEqual(game.seen, seen) {
+		t.Errorf("positions seen %v after taking back every move, want %v", game.seen, seen)
+	}
+}
@@ -1,8 +1,15 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"gogame"
+	"io/ioutil"
+	"log"
 	"math/rand"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,11 +19,133 @@ func main() {
 
 	rand.Seed(time.Now().Unix())
 
+	if len(os.Args) > 1 && os.Args[1] == "gtp" {
+		gtpMain(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "match" {
+		matchMain(os.Args[2:])
+		return
+	}
+
+	size := flag.Int("size", int(gogame.DEFAULT_SIZE), "number of rows and columns on the board")
+	sgfFile := flag.String("sgf", "", "file to save the last game to, as SGF")
+	flag.Parse()
+	boardSize := checkedSize(flag.CommandLine, *size)
+
 	for i := 0; i < REPITITIONS; i++ {
-		gameToShow := gogame.MakeGame(gogame.RandomAutomatonPlayer(), gogame.RandomAutomatonPlayer())
+		black := gogame.FuncPlayer("automaton", gogame.RandomAutomatonPlayer())
+		white := gogame.FuncPlayer("automaton", gogame.RandomAutomatonPlayer())
+		gameToShow := gogame.MakeGame(black, white, gogame.BoardSize(boardSize))
 		gameToShow.PlayGame()
 		gameToShow.PrintGame()
+		if *sgfFile != "" {
+			err := ioutil.WriteFile(*sgfFile, []byte(gameToShow.SGF()), 0644)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+}
+
+// Runs a player as a GTP engine on stdin and stdout
+// Usage: playgo gtp [-player name] [-size n]
+func gtpMain(args []string) {
+	flags := flag.NewFlagSet("gtp", flag.ExitOnError)
+	playerName := flags.String("player", "capture", "player to expose: "+strings.Join(playerNames, ", "))
+	size := flags.Int("size", int(gogame.DEFAULT_SIZE), "number of rows and columns on the board")
+	flags.Parse(args)
+	boardSize := checkedSize(flags, *size)
+
+	player, err := playerNamed(*playerName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	engine := gogame.NewGTPEngine(player, gogame.BoardSize(boardSize))
+	engine.Name = "go-player " + *playerName
+	if err := engine.Run(os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
 
+// Plays a player against an external GTP engine, alternating colors
+// Usage: playgo match [-player name] [-games n] [-size n] [-timeout d] [-movetime d] engine [args]
+func matchMain(args []string) {
+	flags := flag.NewFlagSet("match", flag.ExitOnError)
+	playerName := flags.String("player", "capture", "player to test: "+strings.Join(playerNames, ", "))
+	games := flags.Int("games", 2, "number of games to play")
+	size := flags.Int("size", int(gogame.DEFAULT_SIZE), "number of rows and columns on the board")
+	timeout := flags.Duration("timeout", 10*time.Second, "longest wait for the engine to respond")
+	moveTime := flags.Duration("movetime", 0, "longest either player may take over a move, 0 for no limit")
+	flags.Parse(args)
+	boardSize := checkedSize(flags, *size)
+	if flags.NArg() < 1 {
+		log.Fatal("match needs an engine command")
 	}
 
+	player, err := playerNamed(*playerName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	client, err := gogame.NewGTPClient(*timeout, flags.Arg(0), flags.Args()[1:]...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	for i := 0; i < *games; i++ {
+		options := []gogame.GameOption{gogame.BoardSize(boardSize), gogame.MoveTimeLimit(*moveTime)}
+		var game gogame.Game
+		if i%2 == 0 {
+			game = gogame.MakeGame(player, client, options...)
+		} else {
+			game = gogame.MakeGame(client, player, options...)
+		}
+		result := game.PlayGame()
+		fmt.Printf("Game %d: %s against %s\n", i, game.Config.BlackName, game.Config.WhiteName)
+		fmt.Printf("Black %.1f, white %.1f\n", result.BlackScore, result.WhiteScore)
+		result.PrintOut()
+	}
+}
+
+// Returns the board size given to the -size flag, or prints the usage
+// and exits if it is out of range, as for any other bad flag
+func checkedSize(flags *flag.FlagSet, size int) uint8 {
+	if size < int(gogame.MIN_SIZE) || size > int(gogame.MAX_SIZE) {
+		fmt.Fprintf(flags.Output(), "invalid value %d for flag -size: must be from %d to %d\n", size, gogame.MIN_SIZE, gogame.MAX_SIZE)
+		flags.Usage()
+		os.Exit(2)
+	}
+	return uint8(size)
+}
+
+// Names of the players that can be chosen on the command line
+// data:N is the player made from the Nth datafile
+var playerNames = []string{"random", "bad", "surround", "capture", "automaton", "mcts", "data:N"}
+
+// Returns the player with the given name
+func playerNamed(name string) (gogame.Player, error) {
+	switch name {
+	case "random":
+		return gogame.FuncPlayer(name, gogame.RandomPlayer), nil
+	case "bad":
+		return gogame.FuncPlayer(name, gogame.BadPlayer), nil
+	case "surround":
+		return gogame.FuncPlayer(name, gogame.SurroundPlayer), nil
+	case "capture":
+		return gogame.FuncPlayer(name, gogame.CapturePlayer), nil
+	case "automaton":
+		return gogame.FuncPlayer(name, gogame.RandomAutomatonPlayer()), nil
+	case "mcts":
+		return gogame.MCTSPlayer(gogame.DefaultMCTSOptions()), nil
+	}
+	if strings.HasPrefix(name, "data:") {
+		i, err := strconv.Atoi(strings.TrimPrefix(name, "data:"))
+		if err != nil {
+			return nil, fmt.Errorf("no datafile %q", name)
+		}
+		return gogame.PlayerMaker(i)
+	}
+	return nil, fmt.Errorf("unknown player %q", name)
 }